@@ -0,0 +1,98 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodyInMessage caps how much of an unparsed error body ends up in APIError.Error
+const maxErrorBodyInMessage = 200
+
+// APIError is returned when the Salesforce API responds with an error status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URI        string                   // path relative to /services/data/<version>, or a full url
+	Errors     []SalesforceErrorMessage // empty when the body holds no Salesforce errors
+	Body       string                   // raw response body
+}
+
+func newAPIError(resp *http.Response, payload requestPayload, body []byte) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Method:     payload.method,
+		URI:        payload.uri,
+		Errors:     parseSalesforceErrors(body),
+		Body:       string(body),
+	}
+}
+
+func (e *APIError) Error() string {
+	status := e.Status
+	if status == "" || status == strconv.Itoa(e.StatusCode) {
+		status = strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	}
+	msg := status + ": " + e.Method + " " + e.URI
+
+	if len(e.Errors) == 0 {
+		// html maintenance pages are left out, they are kept in Body
+		body := strings.TrimSpace(e.Body)
+		if body != "" && !strings.HasPrefix(body, "<") {
+			if len(body) > maxErrorBodyInMessage {
+				body = body[:maxErrorBodyInMessage] + "..."
+			}
+			msg += ": " + body
+		}
+		return msg
+	}
+	messages := make([]string, len(e.Errors))
+	for i, sfError := range e.Errors {
+		messages[i] = sfError.ErrorCode + ": " + sfError.Message
+	}
+	return msg + ": " + strings.Join(messages, "; ")
+}
+
+// HasErrorCode reports whether Salesforce returned the error code, e.g. DUPLICATE_VALUE
+func (e *APIError) HasErrorCode(code string) bool {
+	return slices.ContainsFunc(e.Errors, func(sfError SalesforceErrorMessage) bool {
+		return sfError.ErrorCode == code
+	})
+}
+
+// IsErrorCode reports whether err is an *APIError with the error code, e.g. ENTITY_IS_DELETED
+func IsErrorCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.HasErrorCode(code)
+}
+
+// parseSalesforceErrors reads an error body. Most endpoints return a list of errors, some return
+// a single one, and OAuth style bodies are turned into an error with the OAuth error as code.
+func parseSalesforceErrors(body []byte) []SalesforceErrorMessage {
+	var sfErrors []SalesforceErrorMessage
+	if err := json.Unmarshal(body, &sfErrors); err == nil {
+		return sfErrors
+	}
+
+	var sfError SalesforceErrorMessage
+	if err := json.Unmarshal(body, &sfError); err == nil && sfError.ErrorCode != "" {
+		return []SalesforceErrorMessage{sfError}
+	}
+
+	var oauthError struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &oauthError); err == nil && oauthError.Error != "" {
+		return []SalesforceErrorMessage{{
+			ErrorCode: oauthError.Error,
+			Message:   oauthError.Description,
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package salesforce
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseSalesforceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []SalesforceErrorMessage
+	}{
+		{
+			name: "list_of_errors",
+			body: `[{"message":"duplicate value found","errorCode":"DUPLICATE_VALUE","fields":["Name"]}]`,
+			want: []SalesforceErrorMessage{{
+				Message:   "duplicate value found",
+				ErrorCode: "DUPLICATE_VALUE",
+				Fields:    []string{"Name"},
+			}},
+		},
+		{
+			name: "single_error",
+			body: `{"message":"entity is deleted","errorCode":"ENTITY_IS_DELETED"}`,
+			want: []SalesforceErrorMessage{{
+				Message:   "entity is deleted",
+				ErrorCode: "ENTITY_IS_DELETED",
+			}},
+		},
+		{
+			name: "oauth_error",
+			body: `{"error":"invalid_grant","error_description":"expired access/refresh token"}`,
+			want: []SalesforceErrorMessage{{
+				Message:   "expired access/refresh token",
+				ErrorCode: "invalid_grant",
+			}},
+		},
+		{
+			name: "html_page",
+			body: "<html><body>Down for maintenance</body></html>",
+			want: nil,
+		},
+		{
+			name: "empty_body",
+			body: "",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSalesforceErrors([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSalesforceErrors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want string
+	}{
+		{
+			name: "salesforce_errors",
+			err: &APIError{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Method:     http.MethodPost,
+				URI:        "/sobjects/Account",
+				Errors: []SalesforceErrorMessage{
+					{ErrorCode: "DUPLICATE_VALUE", Message: "duplicate value found"},
+					{ErrorCode: "REQUIRED_FIELD_MISSING", Message: "Required fields are missing"},
+				},
+			},
+			want: "400 Bad Request: POST /sobjects/Account: DUPLICATE_VALUE: duplicate value found; " +
+				"REQUIRED_FIELD_MISSING: Required fields are missing",
+		},
+		{
+			name: "html_body",
+			err: &APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				Method:     http.MethodGet,
+				URI:        "/limits",
+				Body:       "<html><body>Down for maintenance</body></html>",
+			},
+			want: "503 Service Unavailable: GET /limits",
+		},
+		{
+			name: "text_body",
+			err: &APIError{
+				StatusCode: http.StatusNotFound,
+				Status:     "404",
+				Method:     http.MethodGet,
+				URI:        "/missing",
+				Body:       "not found",
+			},
+			want: "404 Not Found: GET /missing: not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsErrorCode(t *testing.T) {
+	apiErr := &APIError{Errors: []SalesforceErrorMessage{{ErrorCode: "DUPLICATE_VALUE"}}}
+
+	if !IsErrorCode(apiErr, "DUPLICATE_VALUE") {
+		t.Error("IsErrorCode() = false for the error's code")
+	}
+	if !IsErrorCode(fmt.Errorf("inserting: %w", apiErr), "DUPLICATE_VALUE") {
+		t.Error("IsErrorCode() = false for a wrapped error")
+	}
+	if IsErrorCode(apiErr, "ENTITY_IS_DELETED") {
+		t.Error("IsErrorCode() = true for another code")
+	}
+	if IsErrorCode(errors.New("DUPLICATE_VALUE"), "DUPLICATE_VALUE") {
+		t.Error("IsErrorCode() = true for an error that is not an *APIError")
+	}
+}
+
+func TestSalesforce_DoRequest_apiError(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       any
+		status     int
+		wantCode   string
+		wantInBody string
+	}{
+		{
+			name: "salesforce_error",
+			body: []SalesforceErrorMessage{{
+				ErrorCode: "ENTITY_IS_DELETED",
+				Message:   "entity is deleted",
+			}},
+			status:     http.StatusNotFound,
+			wantCode:   "ENTITY_IS_DELETED",
+			wantInBody: "entity is deleted",
+		},
+		{
+			name:       "maintenance_page",
+			body:       "<html>maintenance</html>",
+			status:     http.StatusServiceUnavailable,
+			wantInBody: "maintenance",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, sfAuth := setupTestServer(tt.body, tt.status)
+			defer server.Close()
+			sf := buildSalesforceStruct(sfAuth)
+
+			_, err := sf.DoRequest(t.Context(), http.MethodDelete, "/sobjects/Account/001", nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("DoRequest() error = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Method != http.MethodDelete ||
+				apiErr.URI != "/sobjects/Account/001" || !strings.Contains(apiErr.Body, tt.wantInBody) {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+			if tt.wantCode != "" && !IsErrorCode(err, tt.wantCode) {
+				t.Errorf("IsErrorCode(%s) = false, errors = %v", tt.wantCode, apiErr.Errors)
+			}
+			if tt.wantCode == "" && len(apiErr.Errors) != 0 {
+				t.Errorf("APIError.Errors = %v, want none", apiErr.Errors)
+			}
+		})
+	}
+}
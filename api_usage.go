@@ -0,0 +1,132 @@
+package salesforce
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const limitInfoHeader = "Sforce-Limit-Info"
+
+// apiUsageStaleAfter is how long an observed usage can block requests through the soft limit.
+// Past that a request is let through so that the usage is refreshed.
+var apiUsageStaleAfter = 5 * time.Minute
+
+// ErrAPIUsageSoftLimit is matched by an *APIUsageLimitError with errors.Is
+var ErrAPIUsageSoftLimit = errors.New("api usage soft limit reached")
+
+// APIUsage is the org's API request usage in the last 24 hours, from the Sforce-Limit-Info header
+type APIUsage struct {
+	Used       int
+	Max        int
+	ObservedAt time.Time
+}
+
+// Fraction returns the share of the daily limit that has been used, between 0 and 1
+func (u APIUsage) Fraction() float64 {
+	if u.Max <= 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Max)
+}
+
+// APIUsageLimitError is returned instead of making a request once the usage passes the soft limit
+type APIUsageLimitError struct {
+	Usage     APIUsage
+	SoftLimit float64
+}
+
+func (e *APIUsageLimitError) Error() string {
+	return fmt.Sprintf(
+		"api usage soft limit of %.0f%% reached: %d/%d requests used",
+		e.SoftLimit*100,
+		e.Usage.Used,
+		e.Usage.Max,
+	)
+}
+
+func (e *APIUsageLimitError) Is(target error) bool {
+	return target == ErrAPIUsageSoftLimit
+}
+
+// apiUsageTracker keeps the latest usage reported by Salesforce, safe for concurrent use
+type apiUsageTracker struct {
+	mu    sync.Mutex
+	usage APIUsage
+}
+
+// parseAPIUsage reads the api-usage entry of a Sforce-Limit-Info header like
+// "api-usage=18/5000, per-app-api-usage=17/250(appName=sample-app)"
+func parseAPIUsage(header string) (APIUsage, bool) {
+	for _, entry := range strings.Split(header, ",") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(entry), "api-usage=")
+		if !ok {
+			continue
+		}
+		usedStr, maxStr, ok := strings.Cut(value, "/")
+		if !ok {
+			return APIUsage{}, false
+		}
+		used, usedErr := strconv.Atoi(usedStr)
+		limit, maxErr := strconv.Atoi(maxStr)
+		if usedErr != nil || maxErr != nil {
+			return APIUsage{}, false
+		}
+		return APIUsage{Used: used, Max: limit}, true
+	}
+	return APIUsage{}, false
+}
+
+// recordAPIUsage stores the usage reported by the response and reports it to the hook
+func (conf *configuration) recordAPIUsage(resp *http.Response) {
+	if resp == nil || conf.apiUsage == nil {
+		return
+	}
+	usage, ok := parseAPIUsage(resp.Header.Get(limitInfoHeader))
+	if !ok {
+		return
+	}
+	usage.ObservedAt = time.Now()
+
+	conf.apiUsage.mu.Lock()
+	conf.apiUsage.usage = usage
+	conf.apiUsage.mu.Unlock()
+
+	if conf.apiUsageHook != nil {
+		conf.apiUsageHook(usage)
+	}
+}
+
+func (conf *configuration) latestAPIUsage() (APIUsage, bool) {
+	if conf.apiUsage == nil {
+		return APIUsage{}, false
+	}
+	conf.apiUsage.mu.Lock()
+	defer conf.apiUsage.mu.Unlock()
+	return conf.apiUsage.usage, !conf.apiUsage.usage.ObservedAt.IsZero()
+}
+
+// checkAPIUsageSoftLimit fails fast when the last observed usage is past the soft limit
+func (conf *configuration) checkAPIUsageSoftLimit() error {
+	if conf.apiUsageSoftLimit <= 0 {
+		return nil
+	}
+	usage, ok := conf.latestAPIUsage()
+	if !ok || time.Since(usage.ObservedAt) > apiUsageStaleAfter {
+		return nil
+	}
+	if usage.Fraction() >= conf.apiUsageSoftLimit {
+		return &APIUsageLimitError{Usage: usage, SoftLimit: conf.apiUsageSoftLimit}
+	}
+	return nil
+}
+
+// APIUsage returns the org's API usage from the most recent response, false before any
+// response reported it
+func (sf *Salesforce) APIUsage() (APIUsage, bool) {
+	return sf.config.latestAPIUsage()
+}
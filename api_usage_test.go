@@ -0,0 +1,118 @@
+package salesforce
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_parseAPIUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   APIUsage
+		wantOk bool
+	}{
+		{
+			name:   "api_usage",
+			header: "api-usage=18/5000",
+			want:   APIUsage{Used: 18, Max: 5000},
+			wantOk: true,
+		},
+		{
+			name:   "with_per_app_usage",
+			header: "per-app-api-usage=17/250(appName=sample-app), api-usage=25/5000",
+			want:   APIUsage{Used: 25, Max: 5000},
+			wantOk: true,
+		},
+		{
+			name:   "missing",
+			header: "",
+			wantOk: false,
+		},
+		{
+			name:   "malformed",
+			header: "api-usage=18",
+			wantOk: false,
+		},
+		{
+			name:   "not_a_number",
+			header: "api-usage=a/5000",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAPIUsage(tt.header)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseAPIUsage() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSalesforce_APIUsage(t *testing.T) {
+	used := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(limitInfoHeader, "api-usage="+strconv.Itoa(int(used.Add(1)))+"/10")
+		if _, err := w.Write([]byte("{}")); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	used.Store(7)
+	reported := []APIUsage{}
+	sf := buildSalesforceStruct(&authentication{AccessToken: "1234", InstanceUrl: server.URL})
+	for _, option := range []Option{
+		WithAPIUsageHook(func(usage APIUsage) { reported = append(reported, usage) }),
+		WithAPIUsageSoftLimit(0.9),
+	} {
+		if err := option(sf.config); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok := sf.APIUsage(); ok {
+		t.Error("APIUsage() before any request should not be ok")
+	}
+	if _, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	usage, ok := sf.APIUsage()
+	if !ok || usage.Used != 8 || usage.Max != 10 {
+		t.Errorf("APIUsage() = %v, %v", usage, ok)
+	}
+	if len(reported) != 1 || reported[0] != usage {
+		t.Errorf("hook reported %v, want [%v]", reported, usage)
+	}
+
+	// 9/10 reaches the 90% soft limit, the next call fails without a request
+	if _, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	_, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil)
+	limitErr := &APIUsageLimitError{}
+	if !errors.Is(err, ErrAPIUsageSoftLimit) || !errors.As(err, &limitErr) {
+		t.Fatalf("DoRequest() error = %v, want %v", err, ErrAPIUsageSoftLimit)
+	}
+	if limitErr.Usage.Used != 9 || used.Load() != 9 {
+		t.Errorf(
+			"DoRequest() past the soft limit usage = %v, server requests = %d",
+			limitErr.Usage,
+			used.Load(),
+		)
+	}
+
+	// a stale observation lets a request through to refresh the usage
+	apiUsageStaleAfter = 0
+	defer func() { apiUsageStaleAfter = 5 * time.Minute }()
+	time.Sleep(time.Millisecond)
+	if _, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil); err != nil {
+		t.Errorf("DoRequest() with stale usage error = %v", err)
+	}
+}
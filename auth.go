@@ -2,14 +2,21 @@ package salesforce
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -24,6 +31,9 @@ const (
 	AuthFlowClientCredentials
 	AuthFlowAccessToken
 	AuthFlowJWT
+	AuthFlowRefreshToken
+	AuthFlowWebServer
+	AuthFlowDevice
 )
 
 func (a AuthFlowType) String() string {
@@ -36,21 +46,48 @@ func (a AuthFlowType) String() string {
 		return "Access Token"
 	case AuthFlowJWT:
 		return "JWT"
+	case AuthFlowRefreshToken:
+		return "Refresh Token"
+	case AuthFlowWebServer:
+		return "Web Server"
+	case AuthFlowDevice:
+		return "Device"
 	default:
 		return "Unknown"
 	}
 }
 
 type authentication struct {
-	AccessToken string `json:"access_token"`
-	InstanceUrl string `json:"instance_url"`
-	Id          string `json:"id"`
-	TokenType   string `json:"token_type"`
-	Scope       string `json:"scope"`
-	IssuedAt    string `json:"issued_at"`
-	Signature   string `json:"signature"`
-	grantType   string
-	creds       Creds
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	InstanceUrl  string `json:"instance_url"`
+	Id           string `json:"id"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	IssuedAt     string `json:"issued_at"`
+	Signature    string `json:"signature"`
+	grantType    string
+	creds        Creds
+	// mu guards the token fields that may change after Init, along with the fields below
+	mu         sync.RWMutex
+	refresh    *refreshCall // in-flight refresh
+	expiration *refreshCall // in-flight expiration lookup, its result goes to expiresAt
+	expiresAt  time.Time    // cached expiration used for proactive refresh
+	loggedOut  bool         // set by Logout
+	// storeMu orders the token store writes of a refresh with the Delete of Logout
+	storeMu sync.Mutex
+}
+
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// accessToken returns the current access token without racing a refresh
+func (auth *authentication) accessToken() string {
+	auth.mu.RLock()
+	defer auth.mu.RUnlock()
+	return auth.AccessToken
 }
 
 type Creds struct {
@@ -62,53 +99,410 @@ type Creds struct {
 	ConsumerSecret string
 	ConsumerRSAPem string
 	AccessToken    string
+	RefreshToken   string
+	// ConsumerRSAPemPassphrase decrypts ConsumerRSAPem when it is an encrypted PKCS#8 key
+	ConsumerRSAPemPassphrase string
+	// JWTSigner signs the JWT bearer assertion instead of ConsumerRSAPem, e.g. a key held by an agent
+	JWTSigner crypto.Signer
 }
 
 const JwtExpirationTime = 5 * time.Minute
 
+// sessionRefreshTimeout bounds a shared refresh, which doesn't stop when its first caller does
+const sessionRefreshTimeout = time.Minute
+
 const (
 	grantTypeUsernamePassword  = "password"
 	grantTypeClientCredentials = "client_credentials"
 	grantTypeAccessToken       = "access_token"
 	grantTypeJWT               = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	grantTypeRefreshToken      = "refresh_token"
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeDevice            = "device"
 )
 
+// Sentinel errors matched by an *AuthError with errors.Is
+var (
+	// ErrInvalidGrant means the credentials, refresh token or code were rejected
+	ErrInvalidGrant = errors.New("invalid grant")
+	// ErrInvalidClient means the consumer key or secret were rejected
+	ErrInvalidClient = errors.New("invalid client")
+	// ErrIPRestricted means the login was refused because of IP restrictions or login hours
+	ErrIPRestricted = errors.New("ip restricted")
+	// ErrInactiveAccount means the user or org is inactive
+	ErrInactiveAccount = errors.New("inactive user or org")
+	// ErrAuthRateLimited means too many login attempts were made
+	ErrAuthRateLimited = errors.New("authentication rate limit exceeded")
+	// ErrAuthUnavailable means the token endpoint is down or returned something other than an oauth error
+	ErrAuthUnavailable = errors.New("authentication service unavailable")
+)
+
+// ErrLoggedOut is returned by every call made after Logout
+var ErrLoggedOut = errors.New("logged out: please use salesforce.Init() to start a new session")
+
+// AuthError is returned when an OAuth endpoint rejects a request
+type AuthError struct {
+	StatusCode  int
+	Status      string
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	Body        string
+}
+
+func newAuthError(resp *http.Response, body []byte) *AuthError {
+	authErr := &AuthError{}
+	// maintenance pages are html, keep the raw body around for those
+	_ = json.Unmarshal(body, authErr)
+	authErr.StatusCode = resp.StatusCode
+	authErr.Status = resp.Status
+	authErr.Body = string(body)
+	return authErr
+}
+
+func (e *AuthError) Error() string {
+	if e.Code == "" {
+		return e.Status + ": failed authentication"
+	}
+	if e.Description == "" {
+		return e.Status + ": failed authentication: " + e.Code
+	}
+	return e.Status + ": failed authentication: " + e.Code + ": " + e.Description
+}
+
+// Is reports whether the error belongs to the category of one of the sentinel errors
+func (e *AuthError) Is(target error) bool {
+	switch target {
+	case ErrIPRestricted:
+		return e.Code == "invalid_grant" &&
+			(strings.Contains(strings.ToLower(e.Description), "ip restricted") ||
+				strings.Contains(strings.ToLower(e.Description), "login hours"))
+	case ErrInvalidGrant:
+		return e.Code == "invalid_grant" && !e.Is(ErrIPRestricted)
+	case ErrInvalidClient:
+		return e.Code == "invalid_client_id" || e.Code == "invalid_client"
+	case ErrInactiveAccount:
+		return e.Code == "inactive_user" || e.Code == "inactive_org"
+	case ErrAuthRateLimited:
+		return e.Code == "rate_limit_exceeded" || e.StatusCode == http.StatusTooManyRequests
+	case ErrAuthUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError || e.Code == ""
+	}
+	return false
+}
+
+// authFlowPrecedence is the order in which Init tries the flows when none is forced
+var authFlowPrecedence = []AuthFlowType{
+	AuthFlowUsernamePassword,
+	AuthFlowRefreshToken,
+	AuthFlowClientCredentials,
+	AuthFlowAccessToken,
+	AuthFlowJWT,
+	AuthFlowDevice, // last, it prompts the user and only needs Domain and ConsumerKey
+}
+
+// CredsError reports Creds fields that are missing or conflicting for an authentication flow
+type CredsError struct {
+	AuthFlow    AuthFlowType
+	Missing     []string
+	Conflicting []string
+	closest     AuthFlowType // flow the missing fields refer to when AuthFlow is unknown
+}
+
+func (e *CredsError) Error() string {
+	var msg string
+	if e.AuthFlow == AuthFlowUnknown {
+		msg = "creds do not match any authentication flow"
+		if e.closest != AuthFlowUnknown {
+			msg += ", closest is " + e.closest.String() + " flow"
+		}
+	} else {
+		msg = "invalid creds for " + e.AuthFlow.String() + " flow"
+	}
+	if len(e.Missing) > 0 {
+		msg += ": missing " + strings.Join(e.Missing, ", ")
+	}
+	if len(e.Conflicting) > 0 {
+		msg += ": conflicting " + strings.Join(e.Conflicting, ", ")
+	}
+	return msg
+}
+
+// requiredCredsFields reports for each Creds field the flow needs whether it is set
+func requiredCredsFields(creds Creds, authFlow AuthFlowType) map[string]bool {
+	var required map[string]bool
+	switch authFlow {
+	case AuthFlowUsernamePassword:
+		required = map[string]bool{
+			"Domain":         creds.Domain != "",
+			"Username":       creds.Username != "",
+			"Password":       creds.Password != "",
+			"SecurityToken":  creds.SecurityToken != "",
+			"ConsumerKey":    creds.ConsumerKey != "",
+			"ConsumerSecret": creds.ConsumerSecret != "",
+		}
+	case AuthFlowRefreshToken:
+		required = map[string]bool{
+			"Domain":       creds.Domain != "",
+			"ConsumerKey":  creds.ConsumerKey != "",
+			"RefreshToken": creds.RefreshToken != "",
+		}
+	case AuthFlowDevice:
+		required = map[string]bool{
+			"Domain":      creds.Domain != "",
+			"ConsumerKey": creds.ConsumerKey != "",
+		}
+	case AuthFlowClientCredentials:
+		required = map[string]bool{
+			"Domain":         creds.Domain != "",
+			"ConsumerKey":    creds.ConsumerKey != "",
+			"ConsumerSecret": creds.ConsumerSecret != "",
+		}
+	case AuthFlowAccessToken:
+		required = map[string]bool{
+			"AccessToken": creds.AccessToken != "",
+		}
+	case AuthFlowJWT:
+		required = map[string]bool{
+			"Domain":                   creds.Domain != "",
+			"Username":                 creds.Username != "",
+			"ConsumerKey":              creds.ConsumerKey != "",
+			"ConsumerRSAPem|JWTSigner": creds.ConsumerRSAPem != "" || creds.JWTSigner != nil,
+		}
+	}
+	return required
+}
+
+// missingCredsFields lists the Creds fields the flow needs that are not set
+func missingCredsFields(creds Creds, authFlow AuthFlowType) []string {
+	missing := []string{}
+	for field, set := range requiredCredsFields(creds, authFlow) {
+		if !set {
+			missing = append(missing, strings.Replace(field, "|", " or ", 1))
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// conflictingCredsFields lists the credentials of other flows that the flow would ignore.
+// ConsumerSecret and Username are never conflicting since they are harmless extras.
+func conflictingCredsFields(creds Creds, authFlow AuthFlowType) []string {
+	set := map[string]bool{
+		"Password":       creds.Password != "",
+		"SecurityToken":  creds.SecurityToken != "",
+		"AccessToken":    creds.AccessToken != "",
+		"RefreshToken":   creds.RefreshToken != "",
+		"ConsumerRSAPem": creds.ConsumerRSAPem != "",
+		"JWTSigner":      creds.JWTSigner != nil,
+	}
+	used := map[string]bool{}
+	switch authFlow {
+	case AuthFlowUsernamePassword:
+		used["Password"], used["SecurityToken"] = true, true
+	case AuthFlowRefreshToken:
+		// a stored access token is resumed alongside the refresh token
+		used["RefreshToken"], used["AccessToken"] = true, true
+	case AuthFlowAccessToken:
+		used["AccessToken"] = true
+	case AuthFlowJWT:
+		if creds.ConsumerRSAPem != "" && creds.JWTSigner != nil {
+			return []string{"ConsumerRSAPem", "JWTSigner"}
+		}
+		used["ConsumerRSAPem"], used["JWTSigner"] = true, true
+	}
+	conflicting := []string{}
+	for field, isSet := range set {
+		if isSet && !used[field] {
+			conflicting = append(conflicting, field)
+		}
+	}
+	slices.Sort(conflicting)
+	return conflicting
+}
+
+// authFlowForCreds returns the first flow in order of precedence that has all its fields set
+func authFlowForCreds(creds Creds, deviceFlowEnabled bool) AuthFlowType {
+	for _, authFlow := range authFlowPrecedence {
+		if authFlow == AuthFlowDevice && !deviceFlowEnabled {
+			continue
+		}
+		if len(missingCredsFields(creds, authFlow)) == 0 {
+			return authFlow
+		}
+	}
+	return AuthFlowUnknown
+}
+
+// validateCredsForFlow checks creds against a forced flow, or picks the flow from the creds
+// when authFlow is AuthFlowUnknown
+func validateCredsForFlow(
+	creds Creds,
+	authFlow AuthFlowType,
+	deviceFlowEnabled bool,
+) (AuthFlowType, error) {
+	if authFlow == AuthFlowUnknown {
+		authFlow = authFlowForCreds(creds, deviceFlowEnabled)
+		if authFlow != AuthFlowUnknown {
+			return authFlow, nil
+		}
+		// report the flow with the most fields set and the fewest missing
+		credsErr := &CredsError{}
+		bestScore := 0
+		for _, candidate := range authFlowPrecedence {
+			if candidate == AuthFlowDevice && !deviceFlowEnabled {
+				continue
+			}
+			missing := missingCredsFields(creds, candidate)
+			score := len(requiredCredsFields(creds, candidate)) - 2*len(missing)
+			if credsErr.closest == AuthFlowUnknown || score > bestScore {
+				credsErr.Missing = missing
+				credsErr.closest = candidate
+				bestScore = score
+			}
+		}
+		return AuthFlowUnknown, credsErr
+	}
+
+	if authFlow == AuthFlowDevice && !deviceFlowEnabled {
+		return AuthFlowUnknown, errors.New("device flow requires the WithDeviceFlow option")
+	}
+	missing := missingCredsFields(creds, authFlow)
+	conflicting := conflictingCredsFields(creds, authFlow)
+	if len(missing) > 0 || len(conflicting) > 0 {
+		return AuthFlowUnknown, &CredsError{
+			AuthFlow:    authFlow,
+			Missing:     missing,
+			Conflicting: conflicting,
+		}
+	}
+	return authFlow, nil
+}
+
+func (auth *authentication) isLoggedOut() bool {
+	auth.mu.RLock()
+	defer auth.mu.RUnlock()
+	return auth.loggedOut
+}
+
 func validateAuth(sf Salesforce) error {
-	if sf.auth == nil || sf.auth.AccessToken == "" {
+	if sf.auth != nil && sf.auth.isLoggedOut() {
+		return ErrLoggedOut
+	}
+	if sf.auth == nil || sf.auth.accessToken() == "" {
 		return errors.New("not authenticated: please use salesforce.Init()")
 	}
 	return nil
 }
 
-func (conf *configuration) validateAuthentication(ctx context.Context, auth authentication) error {
-	if err := validateAuth(Salesforce{auth: &auth}); err != nil {
+func (conf *configuration) validateAuthentication(ctx context.Context, auth *authentication) error {
+	if err := validateAuth(Salesforce{auth: auth}); err != nil {
 		return err
 	}
-	_, err := doRequest(ctx, &auth, conf, requestPayload{
-		method:  http.MethodGet,
-		uri:     "/limits",
-		content: jsonType,
+	resp, err := doRequest(ctx, auth, conf, requestPayload{
+		method:    http.MethodGet,
+		uri:       "/limits",
+		content:   jsonType,
+		operation: OperationValidateSession,
 	})
 	if err != nil {
 		return err
 	}
+	_ = resp.Body.Close() // the response has no content we need
 
 	return nil
 }
 
-func refreshSession(auth *authentication) error {
+// refreshSessionOnce refreshes the session that was used with staleToken. Concurrent callers
+// share a single refresh, and callers whose token was already replaced return right away.
+// A caller whose context ends stops waiting without failing the refresh for the others.
+func (conf *configuration) refreshSessionOnce(
+	ctx context.Context,
+	auth *authentication,
+	staleToken string,
+) error {
+	auth.mu.Lock()
+	if auth.loggedOut {
+		auth.mu.Unlock()
+		return ErrLoggedOut
+	}
+	if staleToken != "" && auth.AccessToken != staleToken {
+		auth.mu.Unlock()
+		return nil
+	}
+	call := auth.refresh
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		auth.refresh = call
+		// the refresh outlives the caller that starts it, the others wait for its result too
+		go conf.runSessionRefresh(context.WithoutCancel(ctx), auth, call)
+	}
+	auth.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runSessionRefresh performs the refresh shared by refreshSessionOnce callers
+func (conf *configuration) runSessionRefresh(
+	ctx context.Context,
+	auth *authentication,
+	call *refreshCall,
+) {
+	ctx, cancel := context.WithTimeout(ctx, sessionRefreshTimeout)
+	defer cancel()
+
+	call.err = conf.refreshSession(ctx, auth)
+	if call.err == nil {
+		call.err = conf.saveRefreshedToken(ctx, auth)
+	}
+	if call.err != nil {
+		conf.log().LogAttrs(ctx, slog.LevelWarn, "salesforce session refresh failed",
+			slog.String("grant_type", auth.grantType),
+			slog.String("error", call.err.Error()),
+		)
+	} else {
+		conf.log().LogAttrs(ctx, slog.LevelInfo, "salesforce session refreshed",
+			slog.String("grant_type", auth.grantType),
+			slog.String("instance_url", auth.InstanceUrl),
+		)
+	}
+
+	auth.mu.Lock()
+	auth.refresh = nil
+	auth.mu.Unlock()
+	close(call.done)
+}
+
+// saveRefreshedToken saves the refreshed session unless Logout ran in the meantime, which would
+// otherwise find the token back in the store on the next Init
+func (conf *configuration) saveRefreshedToken(ctx context.Context, auth *authentication) error {
+	auth.storeMu.Lock()
+	defer auth.storeMu.Unlock()
+	if auth.isLoggedOut() {
+		return ErrLoggedOut
+	}
+	return conf.saveToken(ctx, auth)
+}
+
+func (conf *configuration) refreshSession(ctx context.Context, auth *authentication) error {
 	var refreshedAuth *authentication
 	var err error
 
 	switch grantType := auth.grantType; grantType {
 	case grantTypeClientCredentials:
-		refreshedAuth, err = clientCredentialsFlow(
+		refreshedAuth, err = conf.clientCredentialsFlow(
+			ctx,
 			auth.InstanceUrl,
 			auth.creds.ConsumerKey,
 			auth.creds.ConsumerSecret,
 		)
 	case grantTypeUsernamePassword:
-		refreshedAuth, err = usernamePasswordFlow(
+		refreshedAuth, err = conf.usernamePasswordFlow(
+			ctx,
 			auth.InstanceUrl,
 			auth.creds.Username,
 			auth.creds.Password,
@@ -117,12 +511,33 @@ func refreshSession(auth *authentication) error {
 			auth.creds.ConsumerSecret,
 		)
 	case grantTypeJWT:
-		refreshedAuth, err = jwtFlow(
+		signer, signerErr := jwtSignerFromCreds(auth.creds)
+		if signerErr != nil {
+			return signerErr
+		}
+		refreshedAuth, err = conf.jwtFlow(
+			ctx,
 			auth.InstanceUrl,
 			auth.creds.Username,
 			auth.creds.ConsumerKey,
-			auth.creds.ConsumerRSAPem,
-			JwtExpirationTime,
+			signer,
+		)
+	case grantTypeRefreshToken, grantTypeAuthorizationCode, grantTypeDevice:
+		auth.mu.RLock()
+		refreshToken := auth.RefreshToken
+		auth.mu.RUnlock()
+		if refreshToken == "" {
+			refreshToken = auth.creds.RefreshToken
+		}
+		if refreshToken == "" {
+			return errors.New("invalid session, no refresh token available")
+		}
+		refreshedAuth, err = conf.refreshTokenFlow(
+			ctx,
+			auth.InstanceUrl,
+			refreshToken,
+			auth.creds.ConsumerKey,
+			auth.creds.ConsumerSecret,
 		)
 	default:
 		return errors.New("invalid session, unable to refresh session")
@@ -136,41 +551,70 @@ func refreshSession(auth *authentication) error {
 		return errors.New("missing refresh auth")
 	}
 
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	if auth.loggedOut {
+		// Logout ran while the refresh was in flight, don't bring the session back
+		return ErrLoggedOut
+	}
 	auth.AccessToken = refreshedAuth.AccessToken
+	auth.expiresAt = time.Time{}
 	auth.IssuedAt = refreshedAuth.IssuedAt
 	auth.Signature = refreshedAuth.Signature
 	auth.Id = refreshedAuth.Id
+	if refreshedAuth.RefreshToken != "" {
+		auth.RefreshToken = refreshedAuth.RefreshToken
+	}
 
 	return nil
 }
 
-func doAuth(url string, body *strings.Reader) (*authentication, error) {
-	resp, err := http.Post(url, "application/x-www-form-urlencoded", body)
+// postForm sends an OAuth form post with the configured HTTP client
+func (conf *configuration) postForm(
+	ctx context.Context,
+	url string,
+	body *strings.Reader,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(string(resp.Status) + ":" + " failed authentication")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "go-salesforce")
+	return conf.httpClient.Do(req)
+}
+
+func (conf *configuration) doAuth(
+	ctx context.Context,
+	url string,
+	body *strings.Reader,
+) (*authentication, error) {
+	resp, err := conf.postForm(ctx, url, body)
+	if err != nil {
+		return nil, err
 	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAuthError(resp, respBody)
+	}
 
 	auth := &authentication{}
 	jsonError := json.Unmarshal(respBody, &auth)
 	if jsonError != nil {
 		return nil, jsonError
 	}
-
-	defer func() {
-		_ = resp.Body.Close() // Ignore error since we've already read what we need
-	}()
 	return auth, nil
 }
 
-func usernamePasswordFlow(
+func (conf *configuration) usernamePasswordFlow(
+	ctx context.Context,
 	domain string,
 	username string,
 	password string,
@@ -187,7 +631,7 @@ func usernamePasswordFlow(
 	}
 	endpoint := "/services/oauth2/token"
 	body := strings.NewReader(payload.Encode())
-	auth, err := doAuth(domain+endpoint, body)
+	auth, err := conf.doAuth(ctx, domain+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +639,8 @@ func usernamePasswordFlow(
 	return auth, nil
 }
 
-func clientCredentialsFlow(
+func (conf *configuration) clientCredentialsFlow(
+	ctx context.Context,
 	domain string,
 	consumerKey string,
 	consumerSecret string,
@@ -207,7 +652,7 @@ func clientCredentialsFlow(
 	}
 	endpoint := "/services/oauth2/token"
 	body := strings.NewReader(payload.Encode())
-	auth, err := doAuth(domain+endpoint, body)
+	auth, err := conf.doAuth(ctx, domain+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
@@ -222,7 +667,7 @@ func (conf *configuration) getAccessTokenAuthentication(
 ) (*authentication, error) {
 	auth := &authentication{InstanceUrl: domain, AccessToken: accessToken}
 	if conf.shouldValidateAuthentication {
-		if err := conf.validateAuthentication(ctx, *auth); err != nil {
+		if err := conf.validateAuthentication(ctx, auth); err != nil {
 			return nil, err
 		}
 	}
@@ -230,31 +675,24 @@ func (conf *configuration) getAccessTokenAuthentication(
 	return auth, nil
 }
 
-func jwtFlow(
+func (conf *configuration) jwtFlow(
+	ctx context.Context,
 	domain string,
 	username string,
 	consumerKey string,
-	consumerRSAPem string,
-	expirationTime time.Duration,
+	signer crypto.Signer,
 ) (*authentication, error) {
-	audience := domain
-	if strings.Contains(audience, "test.salesforce") || strings.Contains(audience, "sandbox") {
-		audience = "https://test.salesforce.com"
-	} else {
-		audience = "https://login.salesforce.com"
+	if err := validateJWTSigner(signer); err != nil {
+		return nil, err
 	}
 	claims := &jwt.MapClaims{
-		"exp": strconv.Itoa(int(time.Now().Unix() + int64(expirationTime.Seconds()))),
-		"aud": audience,
+		"exp": strconv.Itoa(int(time.Now().Unix() + int64(conf.jwtExpiration.Seconds()))),
+		"aud": conf.jwtAudience(domain),
 		"iss": consumerKey,
 		"sub": username,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	signKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(consumerRSAPem))
-	if err != nil {
-		return nil, fmt.Errorf("ParseRSAPrivateKeyFromPEM: %w", err)
-	}
-	tokenString, err := token.SignedString(signKey)
+	token := jwt.NewWithClaims(jwtSigningMethodRS256Signer, claims)
+	tokenString, err := token.SignedString(signer)
 	if err != nil {
 		return nil, fmt.Errorf("jwt.SignedString: %w", err)
 	}
@@ -265,10 +703,499 @@ func jwtFlow(
 	}
 	endpoint := "/services/oauth2/token"
 	body := strings.NewReader(payload.Encode())
-	auth, err := doAuth(domain+endpoint, body)
+	auth, err := conf.doAuth(ctx, domain+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
 	auth.grantType = grantTypeJWT
 	return auth, nil
 }
+
+func (conf *configuration) refreshTokenFlow(
+	ctx context.Context,
+	domain string,
+	refreshToken string,
+	consumerKey string,
+	consumerSecret string,
+) (*authentication, error) {
+	payload := url.Values{
+		"grant_type":    {grantTypeRefreshToken},
+		"client_id":     {consumerKey},
+		"refresh_token": {refreshToken},
+	}
+	// the consumer secret is optional for connected apps that don't require it
+	if consumerSecret != "" {
+		payload.Set("client_secret", consumerSecret)
+	}
+	endpoint := "/services/oauth2/token"
+	body := strings.NewReader(payload.Encode())
+	auth, err := conf.doAuth(ctx, domain+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	// salesforce doesn't rotate refresh tokens by default, keep the one we used
+	if auth.RefreshToken == "" {
+		auth.RefreshToken = refreshToken
+	}
+	auth.grantType = grantTypeRefreshToken
+	return auth, nil
+}
+
+// WebServerFlow performs the OAuth 2.0 web server (authorization code) flow with PKCE.
+// Creds must contain Domain and ConsumerKey, ConsumerSecret is only sent when set.
+type WebServerFlow struct {
+	creds       Creds
+	redirectURL string
+	config      *configuration
+	mu          sync.Mutex
+	pending     map[string]pendingLogin // logins started by AuthCodeURL, by state
+}
+
+type pendingLogin struct {
+	verifier string // PKCE code_verifier
+	created  time.Time
+}
+
+// webServerLoginTimeout is how long a login started by AuthCodeURL can take to come back
+const webServerLoginTimeout = 15 * time.Minute
+
+// NewWebServerFlow prepares an authorization code flow that redirects back to redirectURL.
+// The options configure the *Salesforce instances returned by Exchange. They share the
+// configuration, so a token store set with WithTokenStore holds the session of whichever
+// user logged in or refreshed last; only use one when a single user logs in.
+func NewWebServerFlow(creds Creds, redirectURL string, options ...Option) (*WebServerFlow, error) {
+	if creds.Domain == "" || creds.ConsumerKey == "" {
+		return nil, errors.New("web server flow requires Domain and ConsumerKey")
+	}
+	if redirectURL == "" {
+		return nil, errors.New("web server flow requires a redirect URL")
+	}
+	config, err := newConfiguration(options...)
+	if err != nil {
+		return nil, err
+	}
+	return &WebServerFlow{
+		creds:       creds,
+		redirectURL: redirectURL,
+		config:      config,
+		pending:     map[string]pendingLogin{},
+	}, nil
+}
+
+func randomURLSafeString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL returns the URL to send the user to, along with the state that identifies the login
+func (f *WebServerFlow) AuthCodeURL() (string, string, error) {
+	state, err := randomURLSafeString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomURLSafeString()
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	f.mu.Lock()
+	now := time.Now()
+	for pendingState, login := range f.pending {
+		// logins that were abandoned are dropped here, there is no other cleanup
+		if now.Sub(login.created) > webServerLoginTimeout {
+			delete(f.pending, pendingState)
+		}
+	}
+	f.pending[state] = pendingLogin{verifier: verifier, created: now}
+	f.mu.Unlock()
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {f.creds.ConsumerKey},
+		"redirect_uri":          {f.redirectURL},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return f.creds.Domain + "/services/oauth2/authorize?" + params.Encode(), state, nil
+}
+
+// Exchange trades the authorization code returned to the redirect URL for a session
+func (f *WebServerFlow) Exchange(
+	ctx context.Context,
+	code string,
+	state string,
+) (*Salesforce, error) {
+	login, ok := f.takePendingLogin(state)
+	if !ok {
+		return nil, errors.New("unknown, expired or already used oauth state")
+	}
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+
+	auth, err := f.config.authorizationCodeFlow(
+		ctx,
+		f.creds.Domain,
+		code,
+		login.verifier,
+		f.redirectURL,
+		f.creds.ConsumerKey,
+		f.creds.ConsumerSecret,
+	)
+	if err != nil {
+		return nil, err
+	}
+	auth.creds = f.creds
+	auth.creds.RefreshToken = auth.RefreshToken
+
+	if err := f.config.saveToken(ctx, auth); err != nil {
+		return nil, fmt.Errorf("saving token: %w", err)
+	}
+
+	return &Salesforce{
+		auth:     auth,
+		config:   f.config,
+		AuthFlow: AuthFlowWebServer,
+	}, nil
+}
+
+// takePendingLogin removes the login of state, ok is false when it is unknown or expired
+func (f *WebServerFlow) takePendingLogin(state string) (login pendingLogin, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	login, ok = f.pending[state]
+	delete(f.pending, state)
+	if ok && time.Since(login.created) > webServerLoginTimeout {
+		return pendingLogin{}, false
+	}
+	return login, ok
+}
+
+// CallbackHandler returns a handler for the redirect URL. It exchanges the code and reports
+// the resulting session, or the error, to onComplete.
+func (f *WebServerFlow) CallbackHandler(onComplete func(*Salesforce, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var sf *Salesforce
+		var err error
+		if oauthErr := query.Get("error"); oauthErr != "" {
+			f.takePendingLogin(query.Get("state")) // the login is over
+			err = fmt.Errorf("authorization failed: %s: %s", oauthErr, query.Get("error_description"))
+		} else {
+			sf, err = f.Exchange(r.Context(), query.Get("code"), query.Get("state"))
+		}
+		onComplete(sf, err)
+		if err != nil {
+			http.Error(w, "Login failed, you can close this window.", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, "Login complete, you can close this window.")
+	})
+}
+
+func (conf *configuration) authorizationCodeFlow(
+	ctx context.Context,
+	domain string,
+	code string,
+	codeVerifier string,
+	redirectURL string,
+	consumerKey string,
+	consumerSecret string,
+) (*authentication, error) {
+	payload := url.Values{
+		"grant_type":    {grantTypeAuthorizationCode},
+		"code":          {code},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {consumerKey},
+	}
+	if consumerSecret != "" {
+		payload.Set("client_secret", consumerSecret)
+	}
+	endpoint := "/services/oauth2/token"
+	body := strings.NewReader(payload.Encode())
+	auth, err := conf.doAuth(ctx, domain+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	auth.grantType = grantTypeAuthorizationCode
+	return auth, nil
+}
+
+// DeviceAuthorization is what the user needs to approve a device flow login from another device
+type DeviceAuthorization struct {
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	Interval        int    `json:"interval"`
+	ExpiresIn       int    `json:"expires_in"`
+}
+
+type deviceCodeResponse struct {
+	DeviceAuthorization
+	DeviceCode string `json:"device_code"`
+}
+
+var (
+	deviceDefaultPollInterval = 5 * time.Second
+	deviceSlowDownIncrement   = 5 * time.Second
+	deviceDefaultExpiration   = 10 * time.Minute
+)
+
+func (conf *configuration) requestDeviceCode(
+	ctx context.Context,
+	domain string,
+	consumerKey string,
+) (*deviceCodeResponse, error) {
+	payload := url.Values{
+		"response_type": {"device_code"},
+		"client_id":     {consumerKey},
+	}
+	endpoint := "/services/oauth2/token"
+	resp, err := conf.postForm(ctx, domain+endpoint, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAuthError(resp, respBody)
+	}
+
+	deviceCode := &deviceCodeResponse{}
+	if err := json.Unmarshal(respBody, deviceCode); err != nil {
+		return nil, err
+	}
+	if deviceCode.DeviceCode == "" {
+		return nil, errors.New("device code missing from response")
+	}
+	return deviceCode, nil
+}
+
+func (conf *configuration) pollDeviceToken(
+	ctx context.Context,
+	domain string,
+	deviceCode string,
+	consumerKey string,
+	consumerSecret string,
+) (*authentication, error) {
+	payload := url.Values{
+		"grant_type": {grantTypeDevice},
+		"client_id":  {consumerKey},
+		"code":       {deviceCode},
+	}
+	if consumerSecret != "" {
+		payload.Set("client_secret", consumerSecret)
+	}
+	endpoint := "/services/oauth2/token"
+	body := strings.NewReader(payload.Encode())
+	return conf.doAuth(ctx, domain+endpoint, body)
+}
+
+func (conf *configuration) deviceFlow(
+	ctx context.Context,
+	domain string,
+	consumerKey string,
+	consumerSecret string,
+	prompt func(DeviceAuthorization),
+) (*authentication, error) {
+	deviceCode, err := conf.requestDeviceCode(ctx, domain, consumerKey)
+	if err != nil {
+		return nil, err
+	}
+	prompt(deviceCode.DeviceAuthorization)
+
+	interval := time.Duration(deviceCode.Interval) * time.Second
+	if interval <= 0 {
+		interval = deviceDefaultPollInterval
+	}
+	expiration := time.Duration(deviceCode.ExpiresIn) * time.Second
+	if expiration <= 0 {
+		expiration = deviceDefaultExpiration
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiration)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device authorization not completed: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+
+		auth, err := conf.pollDeviceToken(
+			ctx,
+			domain,
+			deviceCode.DeviceCode,
+			consumerKey,
+			consumerSecret,
+		)
+		if err == nil {
+			auth.grantType = grantTypeDevice
+			return auth, nil
+		}
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			return nil, err
+		}
+		switch authErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += deviceSlowDownIncrement
+		default:
+			return nil, fmt.Errorf("device authorization failed: %w", err)
+		}
+	}
+}
+
+type introspectionResponse struct {
+	Active bool  `json:"active"`
+	Exp    int64 `json:"exp"`
+}
+
+func (conf *configuration) introspectToken(
+	ctx context.Context,
+	domain string,
+	token string,
+	consumerKey string,
+	consumerSecret string,
+) (*introspectionResponse, error) {
+	payload := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+		"client_id":       {consumerKey},
+		"client_secret":   {consumerSecret},
+	}
+	endpoint := "/services/oauth2/introspect"
+	resp, err := conf.postForm(ctx, domain+endpoint, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAuthError(resp, respBody)
+	}
+
+	introspection := &introspectionResponse{}
+	if err := json.Unmarshal(respBody, introspection); err != nil {
+		return nil, err
+	}
+	return introspection, nil
+}
+
+// revokeToken revokes an access or refresh token. Revoking a refresh token also revokes
+// the access tokens issued with it.
+func (conf *configuration) revokeToken(ctx context.Context, domain string, token string) error {
+	payload := url.Values{"token": {token}}
+	endpoint := "/services/oauth2/revoke"
+	resp, err := conf.postForm(ctx, domain+endpoint, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		authErr := newAuthError(resp, respBody)
+		if authErr.Code == "invalid_token" {
+			return nil // already expired or revoked
+		}
+		return authErr
+	}
+	return nil
+}
+
+func canRefreshSession(grantType string) bool {
+	return grantType != "" && grantType != grantTypeAccessToken
+}
+
+// sessionExpiration returns when the current access token expires. Flows with a consumer
+// secret ask the introspection endpoint, the others add the configured session lifetime to
+// issued_at, or to now when it can't be parsed. The result is cached until the token changes
+// and concurrent callers share a single lookup.
+func (conf *configuration) sessionExpiration(ctx context.Context, auth *authentication) time.Time {
+	auth.mu.Lock()
+	expiresAt, token, issuedAt := auth.expiresAt, auth.AccessToken, auth.IssuedAt
+	if !expiresAt.IsZero() {
+		auth.mu.Unlock()
+		return expiresAt
+	}
+	if call := auth.expiration; call != nil {
+		auth.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return time.Time{}
+		}
+		auth.mu.RLock()
+		defer auth.mu.RUnlock()
+		return auth.expiresAt
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	auth.expiration = call
+	auth.mu.Unlock()
+
+	if auth.creds.ConsumerKey != "" && auth.creds.ConsumerSecret != "" {
+		introspection, err := conf.introspectToken(
+			ctx,
+			auth.InstanceUrl,
+			token,
+			auth.creds.ConsumerKey,
+			auth.creds.ConsumerSecret,
+		)
+		// introspection failures fall back to the configured lifetime
+		if err == nil && !introspection.Active {
+			expiresAt = time.Now()
+		} else if err == nil && introspection.Exp > 0 {
+			expiresAt = time.Unix(introspection.Exp, 0)
+		}
+	}
+	if expiresAt.IsZero() {
+		issued := time.Now()
+		if issuedAtMillis, err := strconv.ParseInt(issuedAt, 10, 64); err == nil {
+			issued = time.UnixMilli(issuedAtMillis)
+		}
+		expiresAt = issued.Add(conf.sessionLifetime)
+	}
+
+	auth.mu.Lock()
+	if auth.AccessToken == token {
+		auth.expiresAt = expiresAt
+	}
+	auth.expiration = nil
+	auth.mu.Unlock()
+	close(call.done)
+	return expiresAt
+}
+
+// refreshSessionIfExpiring refreshes the session ahead of time once 90% of its lifetime has passed
+func (conf *configuration) refreshSessionIfExpiring(ctx context.Context, auth *authentication) error {
+	if conf.sessionLifetime <= 0 || !canRefreshSession(auth.grantType) {
+		return nil
+	}
+	token := auth.accessToken()
+	expiresAt := conf.sessionExpiration(ctx, auth)
+	if expiresAt.IsZero() || time.Now().Add(conf.sessionLifetime/10).Before(expiresAt) {
+		return nil
+	}
+	return conf.refreshSessionOnce(ctx, auth, token)
+}
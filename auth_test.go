@@ -1,9 +1,25 @@
 package salesforce
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -44,7 +60,7 @@ func Test_validateAuth(t *testing.T) {
 }
 
 func Test_usernamePasswordFlow(t *testing.T) {
-	auth := authentication{
+	auth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -82,7 +98,7 @@ func Test_usernamePasswordFlow(t *testing.T) {
 				consumerKey:    "key",
 				consumerSecret: "secret",
 			},
-			want:    &auth,
+			want:    auth,
 			wantErr: false,
 		},
 		{
@@ -101,7 +117,8 @@ func Test_usernamePasswordFlow(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := usernamePasswordFlow(
+			got, err := getDefaultConfig(t).usernamePasswordFlow(
+				t.Context(),
 				tt.args.domain,
 				tt.args.username,
 				tt.args.password,
@@ -114,14 +131,14 @@ func Test_usernamePasswordFlow(t *testing.T) {
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("loginPassword() = %v, want %v", *got, *tt.want)
+				t.Errorf("loginPassword() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
 func Test_clientCredentialsFlow(t *testing.T) {
-	auth := authentication{
+	auth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -153,7 +170,7 @@ func Test_clientCredentialsFlow(t *testing.T) {
 				consumerKey:    "key",
 				consumerSecret: "secret",
 			},
-			want:    &auth,
+			want:    auth,
 			wantErr: false,
 		},
 		{
@@ -169,7 +186,8 @@ func Test_clientCredentialsFlow(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := clientCredentialsFlow(
+			got, err := getDefaultConfig(t).clientCredentialsFlow(
+				t.Context(),
 				tt.args.domain,
 				tt.args.consumerKey,
 				tt.args.consumerSecret,
@@ -186,7 +204,7 @@ func Test_clientCredentialsFlow(t *testing.T) {
 }
 
 func Test_setAccessToken(t *testing.T) {
-	auth := authentication{
+	auth := &authentication{
 		InstanceUrl: "example.com",
 		AccessToken: "1234",
 	}
@@ -212,7 +230,7 @@ func Test_setAccessToken(t *testing.T) {
 				domain:      server.URL,
 				accessToken: "1234",
 			},
-			want:    &auth,
+			want:    auth,
 			wantErr: false,
 		},
 		{
@@ -255,7 +273,7 @@ func Test_setAccessToken(t *testing.T) {
 }
 
 func Test_refreshSession(t *testing.T) {
-	refreshedAuth := authentication{
+	refreshedAuth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -297,6 +315,19 @@ func Test_refreshSession(t *testing.T) {
 	defer serverJwt.Close()
 	sfAuthJwt.grantType = grantTypeJWT
 
+	serverRefreshToken, sfAuthRefreshToken := setupTestServer(refreshedAuth, http.StatusOK)
+	sfAuthRefreshToken.creds = Creds{
+		Domain:       serverRefreshToken.URL,
+		ConsumerKey:  "key",
+		RefreshToken: "refresh",
+	}
+	defer serverRefreshToken.Close()
+	sfAuthRefreshToken.grantType = grantTypeRefreshToken
+
+	serverNoRefreshToken, sfAuthNoRefreshToken := setupTestServer(refreshedAuth, http.StatusOK)
+	defer serverNoRefreshToken.Close()
+	sfAuthNoRefreshToken.grantType = grantTypeAuthorizationCode
+
 	serverNoGrantType, sfAuthNoGrantType := setupTestServer(refreshedAuth, http.StatusOK)
 	defer serverNoGrantType.Close()
 
@@ -318,38 +349,49 @@ func Test_refreshSession(t *testing.T) {
 	}{
 		{
 			name:    "refresh_client_credentials",
-			args:    args{auth: &sfAuthClientCredentials},
+			args:    args{auth: sfAuthClientCredentials},
 			wantErr: false,
 		},
 		{
 			name:    "refresh_username_password",
-			args:    args{auth: &sfAuthUserNamePassword},
+			args:    args{auth: sfAuthUserNamePassword},
 			wantErr: false,
 		},
 		{
 			name:    "refresh_jwt",
-			args:    args{auth: &sfAuthJwt},
+			args:    args{auth: sfAuthJwt},
 			wantErr: false,
 		},
+		{
+			name:    "refresh_refresh_token",
+			args:    args{auth: sfAuthRefreshToken},
+			wantErr: false,
+		},
+		{
+			name:    "error_authorization_code_without_refresh_token",
+			args:    args{auth: sfAuthNoRefreshToken},
+			wantErr: true,
+		},
 		{
 			name:    "error_no_grant_type",
-			args:    args{auth: &sfAuthNoGrantType},
+			args:    args{auth: sfAuthNoGrantType},
 			wantErr: true,
 		},
 		{
 			name:    "error_bad_request",
-			args:    args{auth: &sfAuthBadRequest},
+			args:    args{auth: sfAuthBadRequest},
 			wantErr: true,
 		},
 		{
 			name:    "no_refresh",
-			args:    args{auth: &sfAuthNoRefresh},
+			args:    args{auth: sfAuthNoRefresh},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := refreshSession(tt.args.auth); (err != nil) != tt.wantErr {
+			config := getDefaultConfig(t)
+			if err := config.refreshSession(t.Context(), tt.args.auth); (err != nil) != tt.wantErr {
 				t.Errorf("refreshSession() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -357,7 +399,7 @@ func Test_refreshSession(t *testing.T) {
 }
 
 func Test_jwtFlow(t *testing.T) {
-	auth := authentication{
+	auth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -372,12 +414,14 @@ func Test_jwtFlow(t *testing.T) {
 	defer badServer.Close()
 
 	sampleKey, _ := os.ReadFile("test/sample_key.pem")
+	sampleSigner, _ := parseRSAPrivateKeyPEM(string(sampleKey), "")
+	ecdsaSigner, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 
 	type args struct {
-		domain         string
-		username       string
-		consumerKey    string
-		consumerRSAPem string
+		domain      string
+		username    string
+		consumerKey string
+		signer      crypto.Signer
 	}
 	tests := []struct {
 		name    string
@@ -388,21 +432,32 @@ func Test_jwtFlow(t *testing.T) {
 		{
 			name: "authentication_success",
 			args: args{
-				domain:         server.URL,
-				username:       "user",
-				consumerKey:    "key",
-				consumerRSAPem: string(sampleKey),
+				domain:      server.URL,
+				username:    "user",
+				consumerKey: "key",
+				signer:      sampleSigner,
 			},
-			want:    &auth,
+			want:    auth,
 			wantErr: false,
 		},
 		{
 			name: "authentication_fail",
 			args: args{
-				domain:         badServer.URL,
-				username:       "user",
-				consumerKey:    "key",
-				consumerRSAPem: string(sampleKey),
+				domain:      badServer.URL,
+				username:    "user",
+				consumerKey: "key",
+				signer:      sampleSigner,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unsupported_signer",
+			args: args{
+				domain:      server.URL,
+				username:    "user",
+				consumerKey: "key",
+				signer:      ecdsaSigner,
 			},
 			want:    nil,
 			wantErr: true,
@@ -410,12 +465,12 @@ func Test_jwtFlow(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := jwtFlow(
+			got, err := getDefaultConfig(t).jwtFlow(
+				t.Context(),
 				tt.args.domain,
 				tt.args.username,
 				tt.args.consumerKey,
-				tt.args.consumerRSAPem,
-				1*time.Minute,
+				tt.args.signer,
 			)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("jwtFlow() error = %v, wantErr %v", err, tt.wantErr)
@@ -428,6 +483,628 @@ func Test_jwtFlow(t *testing.T) {
 	}
 }
 
+func Test_refreshTokenFlow(t *testing.T) {
+	auth := &authentication{
+		AccessToken: "1234",
+		InstanceUrl: "example.com",
+		Id:          "123abc",
+		IssuedAt:    "01/01/1970",
+		Signature:   "signed",
+	}
+	var gotForm url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		gotForm = r.PostForm
+		body, _ := json.Marshal(auth)
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	badServer, _ := setupTestServer(auth, http.StatusBadRequest)
+	defer badServer.Close()
+
+	type args struct {
+		domain         string
+		refreshToken   string
+		consumerKey    string
+		consumerSecret string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantSecret bool
+		wantErr    bool
+	}{
+		{
+			name: "authentication_success",
+			args: args{
+				domain:         server.URL,
+				refreshToken:   "refresh",
+				consumerKey:    "key",
+				consumerSecret: "secret",
+			},
+			wantSecret: true,
+			wantErr:    false,
+		},
+		{
+			name: "authentication_success_no_secret",
+			args: args{
+				domain:       server.URL,
+				refreshToken: "refresh",
+				consumerKey:  "key",
+			},
+			wantSecret: false,
+			wantErr:    false,
+		},
+		{
+			name: "authentication_fail",
+			args: args{
+				domain:       badServer.URL,
+				refreshToken: "refresh",
+				consumerKey:  "key",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDefaultConfig(t).refreshTokenFlow(
+				t.Context(),
+				tt.args.domain,
+				tt.args.refreshToken,
+				tt.args.consumerKey,
+				tt.args.consumerSecret,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("refreshTokenFlow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotForm.Get("grant_type") != grantTypeRefreshToken ||
+				gotForm.Get("refresh_token") != tt.args.refreshToken {
+				t.Errorf("refreshTokenFlow() sent form %v", gotForm)
+			}
+			if gotForm.Has("client_secret") != tt.wantSecret {
+				t.Errorf("refreshTokenFlow() client_secret sent = %v, want %v",
+					gotForm.Has("client_secret"), tt.wantSecret)
+			}
+			if got.AccessToken != auth.AccessToken || got.RefreshToken != tt.args.refreshToken ||
+				got.grantType != grantTypeRefreshToken {
+				t.Errorf("refreshTokenFlow() = %v", got)
+			}
+		})
+	}
+}
+
+func TestWebServerFlow(t *testing.T) {
+	var challenge string
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("grant_type") != grantTypeAuthorizationCode ||
+			r.PostForm.Get("code") != "authcode" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := json.Marshal(authentication{
+			AccessToken:  "1234",
+			RefreshToken: "refresh",
+			InstanceUrl:  "example.com",
+		})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer authServer.Close()
+
+	_, err := NewWebServerFlow(Creds{Domain: authServer.URL}, "http://localhost/callback")
+	if err == nil {
+		t.Error("NewWebServerFlow() without ConsumerKey should return an error")
+	}
+
+	store := NewMemoryTokenStore()
+	flow, err := NewWebServerFlow(
+		Creds{Domain: authServer.URL, ConsumerKey: "key"},
+		"http://localhost/callback",
+		WithTokenStore(store),
+	)
+	if err != nil {
+		t.Fatalf("NewWebServerFlow() error = %v", err)
+	}
+
+	authURL, state, err := flow.AuthCodeURL()
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	params := parsed.Query()
+	if parsed.Path != "/services/oauth2/authorize" || params.Get("state") != state ||
+		params.Get("client_id") != "key" || params.Get("code_challenge_method") != "S256" {
+		t.Fatalf("AuthCodeURL() = %v", authURL)
+	}
+	challenge = params.Get("code_challenge")
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "unknown_state",
+			query:      "?code=authcode&state=unknown",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+		{
+			name:       "authorization_denied",
+			query:      "?error=access_denied&error_description=end-user+denied+authorization",
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+		{
+			name:       "exchange_success",
+			query:      "?code=authcode&state=" + state,
+			wantStatus: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "state_reused",
+			query:      "?code=authcode&state=" + state,
+			wantStatus: http.StatusBadRequest,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Salesforce
+			var gotErr error
+			handler := flow.CallbackHandler(func(sf *Salesforce, err error) {
+				got, gotErr = sf, err
+			})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("CallbackHandler() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("CallbackHandler() error = %v, wantErr %v", gotErr, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.GetAuthFlow() != AuthFlowWebServer || got.GetAccessToken() != "1234" ||
+				got.auth.creds.RefreshToken != "refresh" {
+				t.Errorf("CallbackHandler() session = %v, flow %v", got.auth, got.GetAuthFlow())
+			}
+			if stored, _ := store.Load(t.Context()); stored == nil || stored.RefreshToken != "refresh" {
+				t.Errorf("CallbackHandler() stored token = %v", stored)
+			}
+		})
+	}
+}
+
+func TestWebServerFlow_pendingLogins(t *testing.T) {
+	flow, err := NewWebServerFlow(Creds{Domain: "https://login.example.com", ConsumerKey: "key"},
+		"http://localhost/callback")
+	if err != nil {
+		t.Fatalf("NewWebServerFlow() error = %v", err)
+	}
+	abandon := func(state string) {
+		login := flow.pending[state]
+		login.created = login.created.Add(-webServerLoginTimeout - time.Minute)
+		flow.pending[state] = login
+	}
+
+	_, expired, _ := flow.AuthCodeURL()
+	abandon(expired)
+	if _, err := flow.Exchange(t.Context(), "authcode", expired); err == nil {
+		t.Error("Exchange() with an expired state should return an error")
+	}
+
+	_, abandoned, _ := flow.AuthCodeURL()
+	abandon(abandoned)
+	_, denied, _ := flow.AuthCodeURL()
+	if _, ok := flow.pending[abandoned]; ok || len(flow.pending) != 1 {
+		t.Errorf("AuthCodeURL() kept %d pending logins, want only the new one", len(flow.pending))
+	}
+
+	handler := flow.CallbackHandler(func(*Salesforce, error) {})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet,
+		"/callback?error=access_denied&state="+denied, nil))
+	if len(flow.pending) != 0 {
+		t.Errorf("CallbackHandler() kept the login of a denied authorization: %v", flow.pending)
+	}
+}
+
+func Test_deviceFlow(t *testing.T) {
+	pollInterval, slowDownIncrement := deviceDefaultPollInterval, deviceSlowDownIncrement
+	deviceDefaultPollInterval = time.Millisecond
+	deviceSlowDownIncrement = time.Millisecond
+	t.Cleanup(func() {
+		deviceDefaultPollInterval, deviceSlowDownIncrement = pollInterval, slowDownIncrement
+	})
+
+	newDeviceServer := func(pollResponses []string) *httptest.Server {
+		polls := 0
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				panic(err)
+			}
+			var body []byte
+			if r.PostForm.Get("response_type") == "device_code" {
+				body, _ = json.Marshal(deviceCodeResponse{
+					DeviceAuthorization: DeviceAuthorization{
+						UserCode:        "ABCD-EFGH",
+						VerificationURI: "https://example.com/setup/connect",
+					},
+					DeviceCode: "devicecode",
+				})
+			} else if r.PostForm.Get("grant_type") != grantTypeDevice ||
+				r.PostForm.Get("code") != "devicecode" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			} else if polls < len(pollResponses) {
+				w.WriteHeader(http.StatusBadRequest)
+				body, _ = json.Marshal(map[string]string{"error": pollResponses[polls]})
+				polls++
+			} else {
+				body, _ = json.Marshal(authentication{AccessToken: "1234", RefreshToken: "refresh"})
+			}
+			if _, err := w.Write(body); err != nil {
+				panic(err)
+			}
+		}))
+	}
+
+	pendingServer := newDeviceServer([]string{"authorization_pending", "slow_down"})
+	defer pendingServer.Close()
+	deniedServer := newDeviceServer([]string{"authorization_pending", "access_denied"})
+	defer deniedServer.Close()
+	badServer, _ := setupTestServer("", http.StatusBadRequest)
+	defer badServer.Close()
+
+	tests := []struct {
+		name    string
+		domain  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "authorization_success_after_pending_and_slow_down",
+			domain:  pendingServer.URL,
+			want:    "1234",
+			wantErr: false,
+		},
+		{
+			name:    "authorization_denied",
+			domain:  deniedServer.URL,
+			wantErr: true,
+		},
+		{
+			name:    "device_code_request_fail",
+			domain:  badServer.URL,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompted DeviceAuthorization
+			config := getDefaultConfig(t)
+			got, err := config.deviceFlow(t.Context(), tt.domain, "key", "", func(d DeviceAuthorization) {
+				prompted = d
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deviceFlow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if prompted.UserCode != "ABCD-EFGH" || prompted.VerificationURI == "" {
+				t.Errorf("deviceFlow() prompted with %v", prompted)
+			}
+			if got.AccessToken != tt.want || got.RefreshToken != "refresh" ||
+				got.grantType != grantTypeDevice {
+				t.Errorf("deviceFlow() = %v", got)
+			}
+		})
+	}
+
+	t.Run("context_canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		config := getDefaultConfig(t)
+		_, err := config.deviceFlow(ctx, pendingServer.URL, "key", "", func(DeviceAuthorization) {
+			cancel()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("deviceFlow() error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func Test_doAuthError(t *testing.T) {
+	newServer := func(status int, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			if _, err := w.Write([]byte(body)); err != nil {
+				panic(err)
+			}
+		}))
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+		wantIs   error
+		wantNot  error
+	}{
+		{
+			name:     "invalid_grant",
+			status:   http.StatusBadRequest,
+			body:     `{"error":"invalid_grant","error_description":"authentication failure"}`,
+			wantCode: "invalid_grant",
+			wantIs:   ErrInvalidGrant,
+			wantNot:  ErrAuthUnavailable,
+		},
+		{
+			name:     "invalid_client_id",
+			status:   http.StatusBadRequest,
+			body:     `{"error":"invalid_client_id","error_description":"client identifier invalid"}`,
+			wantCode: "invalid_client_id",
+			wantIs:   ErrInvalidClient,
+			wantNot:  ErrInvalidGrant,
+		},
+		{
+			name:     "ip_restricted",
+			status:   http.StatusBadRequest,
+			body:     `{"error":"invalid_grant","error_description":"ip restricted or invalid login hours"}`,
+			wantCode: "invalid_grant",
+			wantIs:   ErrIPRestricted,
+			wantNot:  ErrInvalidGrant,
+		},
+		{
+			name:     "inactive_user",
+			status:   http.StatusBadRequest,
+			body:     `{"error":"inactive_user","error_description":"user is inactive"}`,
+			wantCode: "inactive_user",
+			wantIs:   ErrInactiveAccount,
+			wantNot:  ErrInvalidClient,
+		},
+		{
+			name:     "maintenance_page",
+			status:   http.StatusServiceUnavailable,
+			body:     "<html>Down for maintenance</html>",
+			wantCode: "",
+			wantIs:   ErrAuthUnavailable,
+			wantNot:  ErrInvalidGrant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(tt.status, tt.body)
+			defer server.Close()
+
+			config := getDefaultConfig(t)
+			_, err := config.doAuth(t.Context(), server.URL, strings.NewReader(""))
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("doAuth() error = %v, want *AuthError", err)
+			}
+			if authErr.StatusCode != tt.status || authErr.Code != tt.wantCode ||
+				authErr.Body != tt.body {
+				t.Errorf("doAuth() error = %+v", authErr)
+			}
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if errors.Is(err, tt.wantNot) {
+				t.Errorf("errors.Is(%v, %v) = true, want false", err, tt.wantNot)
+			}
+		})
+	}
+}
+
+func Test_refreshSessionIfExpiring(t *testing.T) {
+	var tokenRequests, introspectRequests int
+	var introspection introspectionResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/oauth2/token"):
+			tokenRequests++
+			body, _ = json.Marshal(authentication{
+				AccessToken: "refreshed",
+				IssuedAt:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+			})
+		case strings.HasSuffix(r.URL.Path, "/oauth2/introspect"):
+			introspectRequests++
+			body, _ = json.Marshal(introspection)
+		}
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	issuedAt := func(age time.Duration) string {
+		return strconv.FormatInt(now.Add(-age).UnixMilli(), 10)
+	}
+
+	tests := []struct {
+		name            string
+		auth            *authentication
+		lifetime        time.Duration
+		introspection   introspectionResponse
+		wantRefresh     bool
+		wantIntrospects int
+	}{
+		{
+			name: "disabled",
+			auth: &authentication{
+				IssuedAt:  issuedAt(3 * time.Hour),
+				grantType: grantTypeRefreshToken,
+				creds:     Creds{RefreshToken: "refresh"},
+			},
+			wantRefresh: false,
+		},
+		{
+			name: "fresh_session_from_issued_at",
+			auth: &authentication{
+				IssuedAt:  issuedAt(10 * time.Minute),
+				grantType: grantTypeRefreshToken,
+				creds:     Creds{RefreshToken: "refresh"},
+			},
+			lifetime:    time.Hour,
+			wantRefresh: false,
+		},
+		{
+			name: "expiring_session_from_issued_at",
+			auth: &authentication{
+				IssuedAt:  issuedAt(55 * time.Minute),
+				grantType: grantTypeRefreshToken,
+				creds:     Creds{RefreshToken: "refresh"},
+			},
+			lifetime:    time.Hour,
+			wantRefresh: true,
+		},
+		{
+			name: "introspected_session_still_valid",
+			auth: &authentication{
+				IssuedAt:  issuedAt(55 * time.Minute),
+				grantType: grantTypeClientCredentials,
+				creds:     Creds{ConsumerKey: "key", ConsumerSecret: "secret"},
+			},
+			lifetime:        time.Hour,
+			introspection:   introspectionResponse{Active: true, Exp: now.Add(time.Hour).Unix()},
+			wantRefresh:     false,
+			wantIntrospects: 1,
+		},
+		{
+			name: "introspected_session_inactive",
+			auth: &authentication{
+				IssuedAt:  issuedAt(time.Minute),
+				grantType: grantTypeClientCredentials,
+				creds:     Creds{ConsumerKey: "key", ConsumerSecret: "secret"},
+			},
+			lifetime:        time.Hour,
+			introspection:   introspectionResponse{Active: false},
+			wantRefresh:     true,
+			wantIntrospects: 1,
+		},
+		{
+			name: "access_token_flow_cannot_refresh",
+			auth: &authentication{
+				IssuedAt:  issuedAt(3 * time.Hour),
+				grantType: grantTypeAccessToken,
+			},
+			lifetime:    time.Hour,
+			wantRefresh: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRequests, introspectRequests = 0, 0
+			introspection = tt.introspection
+			auth := tt.auth
+			auth.InstanceUrl = server.URL
+			auth.AccessToken = "1234"
+			config := getDefaultConfig(t)
+			config.sessionLifetime = tt.lifetime
+
+			if err := config.refreshSessionIfExpiring(t.Context(), auth); err != nil {
+				t.Fatalf("refreshSessionIfExpiring() error = %v", err)
+			}
+			if (tokenRequests == 1) != tt.wantRefresh || introspectRequests != tt.wantIntrospects {
+				t.Errorf(
+					"refreshSessionIfExpiring() made %d token and %d introspect requests",
+					tokenRequests,
+					introspectRequests,
+				)
+			}
+
+			if tt.wantRefresh {
+				return
+			}
+			// the expiration is cached until the token changes
+			if err := config.refreshSessionIfExpiring(t.Context(), auth); err != nil {
+				t.Fatalf("refreshSessionIfExpiring() error = %v", err)
+			}
+			if introspectRequests > 1 {
+				t.Errorf(
+					"refreshSessionIfExpiring() introspected the same token %d times",
+					introspectRequests,
+				)
+			}
+		})
+	}
+}
+
+func Test_sessionExpiration_concurrent(t *testing.T) {
+	var introspectRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspectRequests.Add(1)
+		time.Sleep(20 * time.Millisecond) // keep the lookup in flight while others pile up
+		body, _ := json.Marshal(introspectionResponse{Active: true, Exp: time.Now().Unix() + 3600})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	auth := &authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "1234",
+		grantType:   grantTypeClientCredentials,
+		creds:       Creds{ConsumerKey: "key", ConsumerSecret: "secret"},
+	}
+	config := getDefaultConfig(t)
+	config.sessionLifetime = time.Hour
+
+	const callers = 20
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if config.sessionExpiration(t.Context(), auth).IsZero() {
+				t.Error("sessionExpiration() returned the zero time")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := introspectRequests.Load(); got != 1 {
+		t.Errorf("introspection endpoint called %d times, want 1", got)
+	}
+}
+
+func Test_sessionExpiration_invalidIssuedAt(t *testing.T) {
+	auth := &authentication{AccessToken: "1234", IssuedAt: "not a number"}
+	config := getDefaultConfig(t)
+	config.sessionLifetime = time.Hour
+
+	before := time.Now()
+	got := config.sessionExpiration(t.Context(), auth)
+	if got.Before(before.Add(time.Hour)) || got.After(time.Now().Add(time.Hour)) {
+		t.Errorf("sessionExpiration() = %v, want the session lifetime from now", got)
+	}
+	if auth.expiresAt != got {
+		t.Errorf("sessionExpiration() cached %v, want %v", auth.expiresAt, got)
+	}
+}
+
 // getDefaultConfig returns a default configuration for internal use
 func getDefaultConfig(t *testing.T) *configuration {
 	t.Helper()
@@ -437,3 +1114,150 @@ func getDefaultConfig(t *testing.T) *configuration {
 	config.shouldValidateAuthentication = true
 	return config
 }
+
+func Test_validateCredsForFlow(t *testing.T) {
+	tests := []struct {
+		name            string
+		creds           Creds
+		authFlow        AuthFlowType
+		deviceFlow      bool
+		want            AuthFlowType
+		wantMissing     []string
+		wantConflicting []string
+		wantErr         bool
+	}{
+		{
+			name: "picked_client_credentials",
+			creds: Creds{
+				Domain:         "https://example.my.salesforce.com",
+				Username:       "user",
+				ConsumerKey:    "key",
+				ConsumerSecret: "secret",
+				ConsumerRSAPem: "pem",
+			},
+			want: AuthFlowClientCredentials,
+		},
+		{
+			name: "forced_jwt_with_consumer_secret",
+			creds: Creds{
+				Domain:         "https://example.my.salesforce.com",
+				Username:       "user",
+				ConsumerKey:    "key",
+				ConsumerSecret: "secret",
+				ConsumerRSAPem: "pem",
+			},
+			authFlow: AuthFlowJWT,
+			want:     AuthFlowJWT,
+		},
+		{
+			name: "forced_jwt_missing_fields",
+			creds: Creds{
+				Domain:      "https://example.my.salesforce.com",
+				ConsumerKey: "key",
+				Password:    "pass",
+			},
+			authFlow:        AuthFlowJWT,
+			wantMissing:     []string{"ConsumerRSAPem or JWTSigner", "Username"},
+			wantConflicting: []string{"Password"},
+			wantErr:         true,
+		},
+		{
+			name: "forced_jwt_key_and_signer",
+			creds: Creds{
+				Domain:         "https://example.my.salesforce.com",
+				Username:       "user",
+				ConsumerKey:    "key",
+				ConsumerRSAPem: "pem",
+				JWTSigner:      agentSigner{},
+			},
+			authFlow:        AuthFlowJWT,
+			wantConflicting: []string{"ConsumerRSAPem", "JWTSigner"},
+			wantErr:         true,
+		},
+		{
+			name: "forced_refresh_token_with_stored_access_token",
+			creds: Creds{
+				Domain:       "https://example.my.salesforce.com",
+				ConsumerKey:  "key",
+				RefreshToken: "refresh",
+				AccessToken:  "1234",
+			},
+			authFlow: AuthFlowRefreshToken,
+			want:     AuthFlowRefreshToken,
+		},
+		{
+			name: "device_flow_enabled_with_consumer_secret",
+			creds: Creds{
+				Domain:         "https://example.my.salesforce.com",
+				ConsumerKey:    "key",
+				ConsumerSecret: "secret",
+			},
+			deviceFlow: true,
+			want:       AuthFlowClientCredentials,
+		},
+		{
+			name: "device_flow_enabled_with_jwt_creds",
+			creds: Creds{
+				Domain:         "https://example.my.salesforce.com",
+				Username:       "user",
+				ConsumerKey:    "key",
+				ConsumerRSAPem: "pem",
+			},
+			deviceFlow: true,
+			want:       AuthFlowJWT,
+		},
+		{
+			name: "device_flow_enabled_with_access_token",
+			creds: Creds{
+				Domain:      "https://example.my.salesforce.com",
+				ConsumerKey: "key",
+				AccessToken: "1234",
+			},
+			deviceFlow: true,
+			want:       AuthFlowAccessToken,
+		},
+		{
+			name:       "picked_device_flow",
+			creds:      Creds{Domain: "https://example.my.salesforce.com", ConsumerKey: "key"},
+			deviceFlow: true,
+			want:       AuthFlowDevice,
+		},
+		{
+			name:     "forced_device_without_prompt",
+			creds:    Creds{Domain: "https://example.my.salesforce.com", ConsumerKey: "key"},
+			authFlow: AuthFlowDevice,
+			wantErr:  true,
+		},
+		{
+			name:        "no_flow_reports_closest",
+			creds:       Creds{Username: "user", ConsumerKey: "key", ConsumerRSAPem: "pem"},
+			wantMissing: []string{"Domain"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateCredsForFlow(tt.creds, tt.authFlow, tt.deviceFlow)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateCredsForFlow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("validateCredsForFlow() = %v, want %v", got, tt.want)
+			}
+			credsErr := &CredsError{}
+			if !errors.As(err, &credsErr) {
+				return
+			}
+			if !slices.Equal(credsErr.Missing, tt.wantMissing) {
+				t.Errorf("CredsError.Missing = %v, want %v", credsErr.Missing, tt.wantMissing)
+			}
+			if !slices.Equal(credsErr.Conflicting, tt.wantConflicting) {
+				t.Errorf(
+					"CredsError.Conflicting = %v, want %v",
+					credsErr.Conflicting,
+					tt.wantConflicting,
+				)
+			}
+		})
+	}
+}
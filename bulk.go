@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -69,16 +70,24 @@ var appFs = afero.NewOsFs() // afero.Fs type is a wrapper around os functions, a
 func (sf *Salesforce) updateJobState(ctx context.Context, job bulkJob, state string) error {
 	job.State = state
 	body, _ := json.Marshal(job)
-	_, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPatch,
-		uri:      "/jobs/ingest/" + job.Id,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodPatch,
+		uri:       "/jobs/ingest/" + job.Id,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		retryable: true, // setting the same job state again is harmless
+		operation: OperationUpdateBulkJobState,
+		jobId:     job.Id,
 	})
 	if err != nil {
 		return err
 	}
+	_ = resp.Body.Close() // the response has no content we need
+	sf.config.log().LogAttrs(ctx, slog.LevelInfo, "bulk job state changed",
+		slog.String("job_id", job.Id),
+		slog.String("state", state),
+	)
 
 	return nil
 }
@@ -89,11 +98,12 @@ func (sf *Salesforce) createBulkJob(
 	body []byte,
 ) (bulkJob, error) {
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPost,
-		uri:      "/jobs/" + jobType,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodPost,
+		uri:       "/jobs/" + jobType,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		operation: OperationCreateBulkJob,
 	})
 	if err != nil {
 		return bulkJob{}, err
@@ -109,17 +119,24 @@ func (sf *Salesforce) createBulkJob(
 	if jsonError != nil {
 		return bulkJob{}, jsonError
 	}
+	sf.config.log().LogAttrs(ctx, slog.LevelInfo, "bulk job created",
+		slog.String("job_id", newJob.Id),
+		slog.String("job_type", jobType),
+		slog.String("state", newJob.State),
+	)
 
 	return *newJob, nil
 }
 
 func (sf *Salesforce) uploadJobData(ctx context.Context, data string, bulkJob bulkJob) error {
-	_, uploadDataErr := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPut,
-		uri:      "/jobs/ingest/" + bulkJob.Id + "/batches",
-		content:  csvType,
-		body:     data,
-		compress: sf.config.compressionHeaders,
+	resp, uploadDataErr := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodPut,
+		uri:       "/jobs/ingest/" + bulkJob.Id + "/batches",
+		content:   csvType,
+		body:      data,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationUploadBulkJobData,
+		jobId:     bulkJob.Id,
 	})
 	if uploadDataErr != nil {
 		if err := sf.updateJobState(ctx, bulkJob, jobStateAborted); err != nil {
@@ -127,6 +144,7 @@ func (sf *Salesforce) uploadJobData(ctx context.Context, data string, bulkJob bu
 		}
 		return uploadDataErr
 	}
+	_ = resp.Body.Close() // the response has no content we need
 	stateErr := sf.updateJobState(ctx, bulkJob, jobStateUploadComplete)
 	if stateErr != nil {
 		return stateErr
@@ -141,10 +159,12 @@ func (sf *Salesforce) getJobResults(
 	bulkJobId string,
 ) (BulkJobResults, error) {
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodGet,
-		uri:      "/jobs/" + jobType + "/" + bulkJobId,
-		content:  jsonType,
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodGet,
+		uri:       "/jobs/" + jobType + "/" + bulkJobId,
+		content:   jsonType,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationGetBulkJob,
+		jobId:     bulkJobId,
 	})
 	if err != nil {
 		return BulkJobResults{}, err
@@ -187,14 +207,19 @@ func (sf *Salesforce) getBulkJobRecords(
 	resultType string,
 ) ([]map[string]any, error) {
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodGet,
-		uri:      "/jobs/ingest/" + bulkJobId + "/" + resultType,
-		content:  jsonType,
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodGet,
+		uri:       "/jobs/ingest/" + bulkJobId + "/" + resultType,
+		content:   jsonType,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationGetBulkJobRecords,
+		jobId:     bulkJobId,
 	})
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
 	reader := csv.NewReader(resp.Body)
 	results, err := csvToMap(*reader)
 	if err != nil {
@@ -217,11 +242,7 @@ func (sf *Salesforce) waitForJobResultsAsync(
 		time.Minute,
 		false,
 		func(context.Context) (bool, error) {
-			bulkJob, reqErr := sf.getJobResults(ctx, jobType, bulkJobId)
-			if reqErr != nil {
-				return true, reqErr
-			}
-			return isBulkJobDone(bulkJob)
+			return sf.pollBulkJob(ctx, jobType, bulkJobId)
 		},
 	)
 	c <- err
@@ -239,16 +260,40 @@ func (sf *Salesforce) waitForJobResults(
 		time.Minute,
 		false,
 		func(context.Context) (bool, error) {
-			bulkJob, reqErr := sf.getJobResults(ctx, jobType, bulkJobId)
-			if reqErr != nil {
-				return true, reqErr
-			}
-			return isBulkJobDone(bulkJob)
+			return sf.pollBulkJob(ctx, jobType, bulkJobId)
 		},
 	)
 	return err
 }
 
+// pollBulkJob reports whether the job is done, logging the state it finished in
+func (sf *Salesforce) pollBulkJob(
+	ctx context.Context,
+	jobType string,
+	bulkJobId string,
+) (done bool, err error) {
+	ctx, span := sf.config.startSpan(ctx, SpanBulkPoll, jobIdAttr(bulkJobId))
+	defer endSpan(span, &err)
+
+	bulkJob, reqErr := sf.getJobResults(ctx, jobType, bulkJobId)
+	if reqErr != nil {
+		return true, reqErr
+	}
+	span.SetAttributes(
+		Attribute{Key: AttrJobState, Value: bulkJob.State},
+		Attribute{Key: AttrRecordsFailed, Value: bulkJob.NumberRecordsFailed},
+	)
+	done, err = isBulkJobDone(bulkJob)
+	if done {
+		sf.config.log().LogAttrs(ctx, slog.LevelInfo, "bulk job finished",
+			slog.String("job_id", bulkJob.Id),
+			slog.String("state", bulkJob.State),
+			slog.Int("records_failed", bulkJob.NumberRecordsFailed),
+		)
+	}
+	return done, err
+}
+
 func isBulkJobDone(bulkJob BulkJobResults) (bool, error) {
 	if bulkJob.State == jobStateJobComplete || bulkJob.State == jobStateFailed {
 		if bulkJob.ErrorMessage != "" {
@@ -276,15 +321,20 @@ func (sf *Salesforce) getQueryJobResults(
 		sf.auth,
 		sf.config,
 		requestPayload{
-			method:   http.MethodGet,
-			uri:      uri,
-			content:  jsonType,
-			compress: sf.config.compressionHeaders,
+			method:    http.MethodGet,
+			uri:       uri,
+			content:   jsonType,
+			compress:  sf.config.compressionHeaders,
+			operation: OperationGetBulkQueryResults,
+			jobId:     bulkJobId,
 		},
 	)
 	if err != nil {
 		return bulkJobQueryResults{}, err
 	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
 
 	reader := csv.NewReader(resp.Body)
 	records, readErr := reader.ReadAll()
@@ -455,6 +505,33 @@ func (sf *Salesforce) constructBulkJobRequest(
 	return job, nil
 }
 
+// doBulkBatch creates a bulk job for one batch of CSV records and uploads them. The job id is
+// returned once the job exists, even if the upload fails.
+func (sf *Salesforce) doBulkBatch(
+	ctx context.Context,
+	sObjectName string,
+	fieldName string,
+	operation string,
+	assignmentRuleId string,
+	data string,
+	recordCount int,
+) (jobId string, err error) {
+	ctx, span := sf.config.startSpan(ctx, SpanBatch,
+		Attribute{Key: AttrOperation, Value: operation},
+		sObjectAttr(sObjectName),
+		batchSizeAttr(recordCount),
+	)
+	defer endSpan(span, &err)
+
+	job, err := sf.constructBulkJobRequest(ctx, sObjectName, operation, fieldName, assignmentRuleId)
+	if err != nil {
+		return "", err
+	}
+	span.SetAttributes(jobIdAttr(job.Id))
+
+	return job.Id, sf.uploadJobData(ctx, data, job)
+}
+
 func (sf *Salesforce) doBulkJob(
 	ctx context.Context,
 	sObjectName string,
@@ -482,26 +559,25 @@ func (sf *Salesforce) doBulkJob(
 		}
 		recordMap = remaining
 
-		job, constructJobErr := sf.constructBulkJobRequest(
+		data, convertErr := mapsToCSV(batch)
+		if convertErr != nil {
+			return jobIds, convertErr
+		}
+
+		jobId, batchErr := sf.doBulkBatch(
 			ctx,
 			sObjectName,
-			operation,
 			fieldName,
+			operation,
 			assignmentRuleId,
+			data,
+			len(batch),
 		)
-		if constructJobErr != nil {
-			return jobIds, constructJobErr
-		}
-		jobIds = append(jobIds, job.Id)
-
-		data, convertErr := mapsToCSV(batch)
-		if convertErr != nil {
-			return jobIds, convertErr
+		if jobId != "" {
+			jobIds = append(jobIds, jobId)
 		}
-
-		uploadErr := sf.uploadJobData(ctx, data, job)
-		if uploadErr != nil {
-			return jobIds, uploadErr
+		if batchErr != nil {
+			return jobIds, batchErr
 		}
 	}
 
@@ -546,19 +622,6 @@ func (sf *Salesforce) doBulkJobWithFile(
 		}
 		records = remaining
 
-		job, constructJobErr := sf.constructBulkJobRequest(
-			ctx,
-			sObjectName,
-			operation,
-			fieldName,
-			assignmentRuleId,
-		)
-		if constructJobErr != nil {
-			jobErrors = errors.Join(jobErrors, constructJobErr)
-			break
-		}
-		jobIds = append(jobIds, job.Id)
-
 		var buf bytes.Buffer
 		w := csv.NewWriter(&buf)
 		batch = append([][]string{headers}, batch...)
@@ -573,9 +636,22 @@ func (sf *Salesforce) doBulkJobWithFile(
 			break
 		}
 
-		uploadErr := sf.uploadJobData(ctx, buf.String(), job)
-		if uploadErr != nil {
-			jobErrors = errors.Join(jobErrors, uploadErr)
+		jobId, batchErr := sf.doBulkBatch(
+			ctx,
+			sObjectName,
+			fieldName,
+			operation,
+			assignmentRuleId,
+			buf.String(),
+			len(batch)-1, // without the header row
+		)
+		if jobId == "" {
+			jobErrors = errors.Join(jobErrors, batchErr)
+			break // the job could not be created
+		}
+		jobIds = append(jobIds, jobId)
+		if batchErr != nil {
+			jobErrors = errors.Join(jobErrors, batchErr)
 		}
 	}
 
@@ -590,6 +666,72 @@ func (sf *Salesforce) doBulkJobWithFile(
 	return jobIds, jobErrors
 }
 
+// bulkJob validates the records and loads them with a bulk job per batch
+func (sf *Salesforce) bulkJob(
+	ctx context.Context,
+	sObjectName string,
+	fieldName string,
+	operation string,
+	records any,
+	batchSize int,
+	waitForResults bool,
+	assignmentRuleId string,
+) ([]string, error) {
+	validationErr := validateBulk(*sf, records, batchSize, false, sObjectName, assignmentRuleId)
+	if validationErr != nil {
+		return []string{}, validationErr
+	}
+
+	jobIds, bulkErr := sf.doBulkJob(
+		ctx,
+		sObjectName,
+		fieldName,
+		operation,
+		records,
+		batchSize,
+		waitForResults,
+		assignmentRuleId,
+	)
+	if bulkErr != nil {
+		return []string{}, bulkErr
+	}
+
+	return jobIds, nil
+}
+
+// bulkJobWithFile validates the file and loads its records with a bulk job per batch
+func (sf *Salesforce) bulkJobWithFile(
+	ctx context.Context,
+	sObjectName string,
+	fieldName string,
+	operation string,
+	filePath string,
+	batchSize int,
+	waitForResults bool,
+	assignmentRuleId string,
+) ([]string, error) {
+	validationErr := validateBulk(*sf, nil, batchSize, true, sObjectName, assignmentRuleId)
+	if validationErr != nil {
+		return []string{}, validationErr
+	}
+
+	jobIds, bulkErr := sf.doBulkJobWithFile(
+		ctx,
+		sObjectName,
+		fieldName,
+		operation,
+		filePath,
+		batchSize,
+		waitForResults,
+		assignmentRuleId,
+	)
+	if bulkErr != nil {
+		return []string{}, bulkErr
+	}
+
+	return jobIds, nil
+}
+
 func (sf *Salesforce) doQueryBulk(ctx context.Context, filePath string, query string) error {
 	queryJobReq := bulkQueryJobCreationRequest{
 		Operation: queryJobType,
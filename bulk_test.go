@@ -60,7 +60,7 @@ func Test_createBulkJob(t *testing.T) {
 		{
 			name: "create_bulk_ingest_job",
 			args: args{
-				sf:      buildSalesforceStruct(&sfAuth),
+				sf:      buildSalesforceStruct(sfAuth),
 				jobType: ingestJobType,
 				body:    ingestBody,
 			},
@@ -70,7 +70,7 @@ func Test_createBulkJob(t *testing.T) {
 		{
 			name: "create_bulk_query_job",
 			args: args{
-				sf:      buildSalesforceStruct(&sfAuth),
+				sf:      buildSalesforceStruct(sfAuth),
 				jobType: queryJobType,
 				body:    queryBody,
 			},
@@ -80,7 +80,7 @@ func Test_createBulkJob(t *testing.T) {
 		{
 			name: "bad_response",
 			args: args{
-				sf:      buildSalesforceStruct(&badRespSfAuth),
+				sf:      buildSalesforceStruct(badRespSfAuth),
 				jobType: queryJobType,
 				body:    queryBody,
 			},
@@ -131,7 +131,7 @@ func Test_getJobResults(t *testing.T) {
 		{
 			name: "get_job_results",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				jobType:   ingestJobType,
 				bulkJobId: "1234",
 			},
@@ -141,7 +141,7 @@ func Test_getJobResults(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badReqSfAuth),
+				sf:        buildSalesforceStruct(badReqSfAuth),
 				jobType:   ingestJobType,
 				bulkJobId: "1234",
 			},
@@ -150,7 +150,7 @@ func Test_getJobResults(t *testing.T) {
 		{
 			name: "bad_response",
 			args: args{
-				sf:        buildSalesforceStruct(&badRespSfAuth),
+				sf:        buildSalesforceStruct(badRespSfAuth),
 				jobType:   ingestJobType,
 				bulkJobId: "1234",
 			},
@@ -257,7 +257,7 @@ func Test_getQueryJobResults(t *testing.T) {
 			t.Fatal(err.Error())
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -280,7 +280,7 @@ func Test_getQueryJobResults(t *testing.T) {
 		{
 			name: "get_single_query_job_result",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "1234",
 				locator:   "",
 			},
@@ -294,7 +294,7 @@ func Test_getQueryJobResults(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badSfAuth),
+				sf:        buildSalesforceStruct(badSfAuth),
 				bulkJobId: "1234",
 				locator:   "",
 			},
@@ -415,7 +415,7 @@ func Test_constructBulkJobRequest(t *testing.T) {
 		{
 			name: "construct_bulk_job_success",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				operation:   insertOperation,
 				fieldName:   "",
@@ -437,7 +437,7 @@ func Test_constructBulkJobRequest(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				operation:   insertOperation,
 				fieldName:   "",
@@ -557,7 +557,7 @@ func Test_doBulkJob(t *testing.T) {
 		{
 			name: "bulk_insert_batch_size_200",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "",
 				operation:   insertOperation,
@@ -578,7 +578,7 @@ func Test_doBulkJob(t *testing.T) {
 		{
 			name: "bulk_upsert_batch_size_1",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "externalId",
 				operation:   upsertOperation,
@@ -601,7 +601,7 @@ func Test_doBulkJob(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				fieldName:   "externalId",
 				operation:   upsertOperation,
@@ -655,7 +655,7 @@ func Test_doBulkJob(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:             buildSalesforceStruct(&sfAuth),
+				sf:             buildSalesforceStruct(sfAuth),
 				sObjectName:    "Account",
 				fieldName:      "",
 				operation:      insertOperation,
@@ -716,7 +716,7 @@ func Test_waitForJobResultsAsync(t *testing.T) {
 		{
 			name: "wait_for_ingest_result",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "1234",
 				jobType:   ingestJobType,
 				interval:  time.Nanosecond,
@@ -727,7 +727,7 @@ func Test_waitForJobResultsAsync(t *testing.T) {
 		{
 			name: "wait_for_query_result",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "1234",
 				jobType:   queryJobType,
 				interval:  time.Nanosecond,
@@ -738,7 +738,7 @@ func Test_waitForJobResultsAsync(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badSfAuth),
+				sf:        buildSalesforceStruct(badSfAuth),
 				bulkJobId: "",
 				jobType:   queryJobType,
 				interval:  time.Nanosecond,
@@ -790,7 +790,7 @@ func Test_waitForJobResults(t *testing.T) {
 		{
 			name: "wait_for_ingest_result",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "1234",
 				jobType:   ingestJobType,
 				interval:  time.Nanosecond,
@@ -800,7 +800,7 @@ func Test_waitForJobResults(t *testing.T) {
 		{
 			name: "wait_for_query_result",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "1234",
 				jobType:   queryJobType,
 				interval:  time.Nanosecond,
@@ -810,7 +810,7 @@ func Test_waitForJobResults(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badSfAuth),
+				sf:        buildSalesforceStruct(badSfAuth),
 				bulkJobId: "",
 				jobType:   queryJobType,
 				interval:  time.Nanosecond,
@@ -846,7 +846,7 @@ func Test_collectQueryResults(t *testing.T) {
 			t.Fatal(err.Error())
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -868,7 +868,7 @@ func Test_collectQueryResults(t *testing.T) {
 		{
 			name: "query_with_locator",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				bulkJobId: "123",
 			},
 			want:    [][]string{{"col"}, {"row"}, {"row"}},
@@ -877,7 +877,7 @@ func Test_collectQueryResults(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badSfAuth),
+				sf:        buildSalesforceStruct(badSfAuth),
 				bulkJobId: "123",
 			},
 			wantErr: true,
@@ -943,7 +943,7 @@ func Test_uploadJobData(t *testing.T) {
 		{
 			name: "update_job_state_success",
 			args: args{
-				sf:      buildSalesforceStruct(&sfAuth),
+				sf:      buildSalesforceStruct(sfAuth),
 				data:    "data",
 				bulkJob: bulkJob{},
 			},
@@ -970,7 +970,7 @@ func Test_uploadJobData(t *testing.T) {
 		{
 			name: "update_job_state_fail_complete",
 			args: args{
-				sf:      buildSalesforceStruct(&badRequestSfAuth),
+				sf:      buildSalesforceStruct(badRequestSfAuth),
 				data:    "data",
 				bulkJob: bulkJob{},
 			},
@@ -1094,7 +1094,7 @@ func Test_updateJobState(t *testing.T) {
 			args: args{
 				job:   bulkJob{},
 				state: "",
-				sf:    buildSalesforceStruct(&badSfAuth),
+				sf:    buildSalesforceStruct(badSfAuth),
 			},
 			wantErr: true,
 		},
@@ -1192,7 +1192,7 @@ func Test_doBulkJobWithFile(t *testing.T) {
 		{
 			name: "bulk_insert_batch_size_200",
 			args: args{
-				sf:             buildSalesforceStruct(&sfAuth),
+				sf:             buildSalesforceStruct(sfAuth),
 				sObjectName:    "Account",
 				fieldName:      "",
 				operation:      insertOperation,
@@ -1206,7 +1206,7 @@ func Test_doBulkJobWithFile(t *testing.T) {
 		{
 			name: "bulk_insert_batch_size_1",
 			args: args{
-				sf:             buildSalesforceStruct(&sfAuth),
+				sf:             buildSalesforceStruct(sfAuth),
 				sObjectName:    "Account",
 				fieldName:      "",
 				operation:      insertOperation,
@@ -1220,7 +1220,7 @@ func Test_doBulkJobWithFile(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:             buildSalesforceStruct(&badReqSfAuth),
+				sf:             buildSalesforceStruct(badReqSfAuth),
 				sObjectName:    "Account",
 				fieldName:      "externalId",
 				operation:      upsertOperation,
@@ -1373,7 +1373,7 @@ func Test_getJobRecordResults(t *testing.T) {
 			t.Fatal(err.Error())
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -1412,7 +1412,7 @@ func Test_getJobRecordResults(t *testing.T) {
 		{
 			name: "successful_get_job_record_results",
 			args: args{
-				sf:             buildSalesforceStruct(&sfAuth),
+				sf:             buildSalesforceStruct(sfAuth),
 				bulkJobResults: BulkJobResults{Id: "1234"},
 			},
 			want: BulkJobResults{
@@ -1429,7 +1429,7 @@ func Test_getJobRecordResults(t *testing.T) {
 		{
 			name: "failed_to_get_successful_records",
 			args: args{
-				sf:             buildSalesforceStruct(&badRequestAuth),
+				sf:             buildSalesforceStruct(badRequestAuth),
 				bulkJobResults: BulkJobResults{Id: "1234"},
 			},
 			want:    BulkJobResults{Id: "1234"},
@@ -1471,7 +1471,7 @@ func Test_getBulkJobRecords(t *testing.T) {
 			t.Fatal(err.Error())
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -1507,7 +1507,7 @@ func Test_getBulkJobRecords(t *testing.T) {
 		{
 			name: "successful_get_failed_job_records",
 			args: args{
-				sf:         buildSalesforceStruct(&sfAuth),
+				sf:         buildSalesforceStruct(sfAuth),
 				bulkJobId:  "1234",
 				resultType: failedResults,
 			},
@@ -1519,7 +1519,7 @@ func Test_getBulkJobRecords(t *testing.T) {
 		{
 			name: "failed_bad_request",
 			args: args{
-				sf:         buildSalesforceStruct(&badReqAuth),
+				sf:         buildSalesforceStruct(badReqAuth),
 				bulkJobId:  "1234",
 				resultType: failedResults,
 			},
@@ -0,0 +1,113 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CallOption sets request headers for the calls made with a context, see WithCallOptions
+type CallOption func(header http.Header) error
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context that applies the options to every request made with it,
+// on top of the options already in ctx. It works with every method that takes a context.
+func WithCallOptions(ctx context.Context, options ...CallOption) context.Context {
+	existing, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	combined := append(append([]CallOption{}, existing...), options...)
+	return context.WithValue(ctx, callOptionsKey{}, combined)
+}
+
+// callHeaders returns the headers set by the context's call options, nil when there are none
+func callHeaders(ctx context.Context) (http.Header, error) {
+	options, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	if len(options) == 0 {
+		return nil, nil
+	}
+	header := http.Header{}
+	for _, option := range options {
+		if option == nil {
+			return nil, errors.New("call option cannot be nil")
+		}
+		if err := option(header); err != nil {
+			return nil, err
+		}
+	}
+	return header, nil
+}
+
+// CallHeader sets any request header, for the ones without a dedicated option
+func CallHeader(key string, value string) CallOption {
+	return func(header http.Header) error {
+		if key == "" {
+			return errors.New("call header key cannot be empty")
+		}
+		header.Set(key, value)
+		return nil
+	}
+}
+
+// AllowDuplicateSave saves records even when a duplicate rule alerts on them
+func AllowDuplicateSave(allowSave bool) CallOption {
+	return CallHeader("Sforce-Duplicate-Rule-Header", "allowSave="+strconv.FormatBool(allowSave))
+}
+
+// AutoAssign sets whether active assignment rules run for cases and leads
+func AutoAssign(autoAssign bool) CallOption {
+	return CallHeader("Sforce-Auto-Assign", strings.ToUpper(strconv.FormatBool(autoAssign)))
+}
+
+// CallClientId identifies the client in the Sforce-Call-Options header, e.g. a partner id
+func CallClientId(clientId string) CallOption {
+	return func(header http.Header) error {
+		if clientId == "" {
+			return errors.New("call client id cannot be empty")
+		}
+		header.Set("Sforce-Call-Options", "client="+clientId)
+		return nil
+	}
+}
+
+// UpdateMRU sets whether the records are added to the user's most recently used list
+func UpdateMRU(updateMru bool) CallOption {
+	return CallHeader("Sforce-Mru", "updateMru="+strconv.FormatBool(updateMru))
+}
+
+// QueryBatchSize sets how many records a query returns per page, between 200 and 2000
+func QueryBatchSize(batchSize int) CallOption {
+	return func(header http.Header) error {
+		if batchSize < 200 || batchSize > 2000 {
+			return errors.New("query batch size must be between 200 and 2000")
+		}
+		header.Set("Sforce-Query-Options", "batchSize="+strconv.Itoa(batchSize))
+		return nil
+	}
+}
+
+// IfModifiedSince only returns the record if it changed after t. An unchanged record fails
+// with an *APIError with status 304.
+func IfModifiedSince(t time.Time) CallOption {
+	return func(header http.Header) error {
+		if t.IsZero() {
+			return errors.New("if modified since time cannot be zero")
+		}
+		header.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+		return nil
+	}
+}
+
+// IfMatch only performs the request if the record's ETag matches. A changed record fails
+// with an *APIError with status 412.
+func IfMatch(etag string) CallOption {
+	return func(header http.Header) error {
+		if etag == "" {
+			return errors.New("if match etag cannot be empty")
+		}
+		header.Set("If-Match", etag)
+		return nil
+	}
+}
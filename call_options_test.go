@@ -0,0 +1,120 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithCallOptions(t *testing.T) {
+	modifiedSince := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		options []CallOption
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "sforce_headers",
+			options: []CallOption{
+				AllowDuplicateSave(true),
+				AutoAssign(false),
+				CallClientId("partner/1"),
+				UpdateMRU(true),
+				QueryBatchSize(500),
+			},
+			want: map[string]string{
+				"Sforce-Duplicate-Rule-Header": "allowSave=true",
+				"Sforce-Auto-Assign":           "FALSE",
+				"Sforce-Call-Options":          "client=partner/1",
+				"Sforce-Mru":                   "updateMru=true",
+				"Sforce-Query-Options":         "batchSize=500",
+			},
+		},
+		{
+			name:    "conditional_headers",
+			options: []CallOption{IfModifiedSince(modifiedSince), IfMatch(`"etag-1"`)},
+			want: map[string]string{
+				"If-Modified-Since": "Sat, 01 Mar 2025 12:00:00 GMT",
+				"If-Match":          `"etag-1"`,
+			},
+		},
+		{
+			name:    "later_option_wins",
+			options: []CallOption{CallHeader("X-Custom", "first"), CallHeader("X-Custom", "second")},
+			want:    map[string]string{"X-Custom": "second"},
+		},
+		{
+			name:    "invalid_batch_size",
+			options: []CallOption{QueryBatchSize(100)},
+			wantErr: true,
+		},
+		{
+			name:    "empty_header_key",
+			options: []CallOption{CallHeader("", "value")},
+			wantErr: true,
+		},
+		{
+			name:    "nil_option",
+			options: []CallOption{nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				body, _ := json.Marshal(SalesforceResult{Id: "001", Success: true})
+				w.WriteHeader(http.StatusCreated)
+				if _, err := w.Write(body); err != nil {
+					panic(err)
+				}
+			}))
+			defer server.Close()
+			sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+
+			ctx := WithCallOptions(t.Context(), tt.options...)
+			_, err := sf.InsertOne(ctx, "Account", map[string]any{"Name": "test"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InsertOne() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if got != nil {
+					t.Error("request was sent despite an invalid call option")
+				}
+				return
+			}
+			for key, value := range tt.want {
+				if got.Get(key) != value {
+					t.Errorf("header %s = %q, want %q", key, got.Get(key), value)
+				}
+			}
+			if got.Get("Authorization") != "Bearer 1234" {
+				t.Errorf("Authorization header = %q", got.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestWithCallOptions_nested(t *testing.T) {
+	outer := WithCallOptions(t.Context(), AutoAssign(true), UpdateMRU(false))
+	inner := WithCallOptions(outer, UpdateMRU(true))
+
+	header, err := callHeaders(inner)
+	if err != nil {
+		t.Fatalf("callHeaders() error = %v", err)
+	}
+	if header.Get("Sforce-Auto-Assign") != "TRUE" || header.Get("Sforce-Mru") != "updateMru=true" {
+		t.Errorf("inner headers = %v", header)
+	}
+	header, _ = callHeaders(outer)
+	if header.Get("Sforce-Mru") != "updateMru=false" {
+		t.Errorf("outer headers changed by the inner context: %v", header)
+	}
+	if header, _ := callHeaders(t.Context()); header != nil {
+		t.Errorf("callHeaders() without options = %v, want nil", header)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 type compositeRequest struct {
@@ -36,6 +38,8 @@ type compositeSubRequestResult struct {
 
 func (sf *Salesforce) doCompositeRequest(
 	ctx context.Context,
+	operation string,
+	sObjectName string,
 	compReq compositeRequest,
 ) (SalesforceResults, error) {
 	body, jsonErr := json.Marshal(compReq)
@@ -43,16 +47,18 @@ func (sf *Salesforce) doCompositeRequest(
 		return SalesforceResults{}, jsonErr
 	}
 	resp, httpErr := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPost,
-		uri:      "/composite",
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodPost,
+		uri:       "/composite",
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		operation: operation,
+		sObject:   sObjectName,
 	})
 	if httpErr != nil {
 		return SalesforceResults{}, httpErr
 	}
-	results, salesforceErrors := processCompositeResponse(*resp, compReq.AllOrNone)
+	results, salesforceErrors := processCompositeResponse(*resp, compReq)
 	if salesforceErrors != nil {
 		return SalesforceResults{}, salesforceErrors
 	}
@@ -115,7 +121,26 @@ func createCompositeRequestForCollection(
 	}, nil
 }
 
-func processCompositeResponse(resp http.Response, allOrNone bool) (SalesforceResults, error) {
+// recordCount returns how many records the sub request writes or deletes
+func (subReq compositeSubRequest) recordCount() int {
+	if subReq.Method != http.MethodDelete {
+		return len(subReq.Body.Records)
+	}
+	deleteUrl, err := url.Parse(subReq.Url)
+	if err != nil {
+		return 0
+	}
+	ids := deleteUrl.Query().Get("ids")
+	if ids == "" {
+		return 0
+	}
+	return strings.Count(ids, ",") + 1
+}
+
+func processCompositeResponse(
+	resp http.Response,
+	compReq compositeRequest,
+) (SalesforceResults, error) {
 	compositeResults := compositeRequestResult{}
 	results := SalesforceResults{}
 
@@ -128,7 +153,16 @@ func processCompositeResponse(resp http.Response, allOrNone bool) (SalesforceRes
 		return SalesforceResults{}, jsonError
 	}
 
-	for _, subResult := range compositeResults.CompositeResponse {
+	for i, subResult := range compositeResults.CompositeResponse {
+		if i < len(compReq.CompositeRequest) && subResult.HttpStatusCode >= http.StatusBadRequest {
+			if recordCount := compReq.CompositeRequest[i].recordCount(); recordCount > 0 {
+				subResult.Body = alignBatchResults(
+					subResult.Body,
+					recordCount,
+					subResult.HttpStatusCode,
+				)
+			}
+		}
 		for _, result := range subResult.Body {
 			if !result.Success {
 				results.HasSalesforceErrors = true
@@ -157,7 +191,7 @@ func (sf *Salesforce) doInsertComposite(
 		recordMap[i]["attributes"] = map[string]string{"type": sObjectName}
 	}
 
-	uri := "/services/data/" + apiVersion + "/composite/sobjects"
+	uri := sf.config.dataPath() + "/composite/sobjects"
 	compReq, compositeErr := createCompositeRequestForCollection(
 		http.MethodPost,
 		uri,
@@ -168,10 +202,16 @@ func (sf *Salesforce) doInsertComposite(
 	if compositeErr != nil {
 		return SalesforceResults{}, compositeErr
 	}
-	results, compositeReqErr := sf.doCompositeRequest(ctx, compReq)
+	results, compositeReqErr := sf.doCompositeRequest(
+		ctx,
+		OperationInsertComposite,
+		sObjectName,
+		compReq,
+	)
 	if compositeReqErr != nil {
 		return SalesforceResults{}, compositeReqErr
 	}
+	correlateResults(results.Results, records)
 
 	return results, nil
 }
@@ -196,7 +236,7 @@ func (sf *Salesforce) doUpdateComposite(
 		}
 	}
 
-	uri := "/services/data/" + apiVersion + "/composite/sobjects"
+	uri := sf.config.dataPath() + "/composite/sobjects"
 	compReq, compositeErr := createCompositeRequestForCollection(
 		http.MethodPatch,
 		uri,
@@ -207,10 +247,16 @@ func (sf *Salesforce) doUpdateComposite(
 	if compositeErr != nil {
 		return SalesforceResults{}, compositeErr
 	}
-	results, compositeReqErr := sf.doCompositeRequest(ctx, compReq)
+	results, compositeReqErr := sf.doCompositeRequest(
+		ctx,
+		OperationUpdateComposite,
+		sObjectName,
+		compReq,
+	)
 	if compositeReqErr != nil {
 		return SalesforceResults{}, compositeReqErr
 	}
+	correlateResults(results.Results, records)
 
 	return results, nil
 }
@@ -240,7 +286,7 @@ func (sf *Salesforce) doUpsertComposite(
 		}
 	}
 
-	uri := "/services/data/" + apiVersion + "/composite/sobjects/" + sObjectName + "/" + fieldName
+	uri := sf.config.dataPath() + "/composite/sobjects/" + sObjectName + "/" + fieldName
 	compReq, compositeErr := createCompositeRequestForCollection(
 		http.MethodPatch,
 		uri,
@@ -251,10 +297,16 @@ func (sf *Salesforce) doUpsertComposite(
 	if compositeErr != nil {
 		return SalesforceResults{}, compositeErr
 	}
-	results, compositeReqErr := sf.doCompositeRequest(ctx, compReq)
+	results, compositeReqErr := sf.doCompositeRequest(
+		ctx,
+		OperationUpsertComposite,
+		sObjectName,
+		compReq,
+	)
 	if compositeReqErr != nil {
 		return SalesforceResults{}, compositeReqErr
 	}
+	correlateResults(results.Results, records)
 
 	return results, nil
 }
@@ -295,7 +347,7 @@ func (sf *Salesforce) doDeleteComposite(
 			}
 		}
 
-		uri := "/services/data/" + apiVersion + "/composite/sobjects/?ids=" + ids + "&allOrNone=" + strconv.FormatBool(
+		uri := sf.config.dataPath() + "/composite/sobjects/?ids=" + ids + "&allOrNone=" + strconv.FormatBool(
 			allOrNone,
 		)
 		subReq := compositeSubRequest{
@@ -312,10 +364,16 @@ func (sf *Salesforce) doDeleteComposite(
 		AllOrNone:        allOrNone,
 		CompositeRequest: subReqs,
 	}
-	results, compositeReqErr := sf.doCompositeRequest(ctx, compReq)
+	results, compositeReqErr := sf.doCompositeRequest(
+		ctx,
+		OperationDeleteComposite,
+		sObjectName,
+		compReq,
+	)
 	if compositeReqErr != nil {
 		return SalesforceResults{}, compositeReqErr
 	}
+	correlateResults(results.Results, records)
 
 	return results, nil
 }
@@ -252,7 +252,10 @@ func Test_processCompositeResponse(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := processCompositeResponse(tt.args.resp, tt.args.allOrNone)
+			got, err := processCompositeResponse(
+				tt.args.resp,
+				compositeRequest{AllOrNone: tt.args.allOrNone},
+			)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("processCompositeResponse() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -328,7 +331,7 @@ func Test_doCompositeRequest(t *testing.T) {
 		{
 			name: "successful_request",
 			args: args{
-				sf:      buildSalesforceStruct(&sfAuth),
+				sf:      buildSalesforceStruct(sfAuth),
 				compReq: compReq,
 			},
 			want: SalesforceResults{
@@ -340,7 +343,7 @@ func Test_doCompositeRequest(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:      buildSalesforceStruct(&badReqSfAuth),
+				sf:      buildSalesforceStruct(badReqSfAuth),
 				compReq: compReq,
 			},
 			want:    SalesforceResults{},
@@ -349,7 +352,7 @@ func Test_doCompositeRequest(t *testing.T) {
 		{
 			name: "salesforce_errors",
 			args: args{
-				sf:      buildSalesforceStruct(&sfErrorSfAuth),
+				sf:      buildSalesforceStruct(sfErrorSfAuth),
 				compReq: compReq,
 			},
 			want:    sfResultsFail,
@@ -358,7 +361,12 @@ func Test_doCompositeRequest(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.args.sf.doCompositeRequest(t.Context(), tt.args.compReq)
+			got, err := tt.args.sf.doCompositeRequest(
+				t.Context(),
+				OperationInsertComposite,
+				"Account",
+				tt.args.compReq,
+			)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("doCompositeRequest() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -403,7 +411,7 @@ func Test_doInsertComposite(t *testing.T) {
 		{
 			name: "successful_insert_composite",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -425,7 +433,7 @@ func Test_doInsertComposite(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -489,7 +497,7 @@ func Test_doUpdateComposite(t *testing.T) {
 		{
 			name: "successful_update_composite",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -513,7 +521,7 @@ func Test_doUpdateComposite(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -525,7 +533,7 @@ func Test_doUpdateComposite(t *testing.T) {
 		{
 			name: "fail_no_id",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -594,7 +602,7 @@ func Test_doUpsertComposite(t *testing.T) {
 		{
 			name: "successful_upsert_composite",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				records: []account{
@@ -619,7 +627,7 @@ func Test_doUpsertComposite(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				records:     "1",
@@ -632,7 +640,7 @@ func Test_doUpsertComposite(t *testing.T) {
 		{
 			name: "fail_no_external_id",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				records: []account{
@@ -701,7 +709,7 @@ func Test_doDeleteComposite(t *testing.T) {
 		{
 			name: "successful_delete_composite_single_batch",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -723,7 +731,7 @@ func Test_doDeleteComposite(t *testing.T) {
 		{
 			name: "successful_delete_composite_multi_batch",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -745,7 +753,7 @@ func Test_doDeleteComposite(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -757,7 +765,7 @@ func Test_doDeleteComposite(t *testing.T) {
 		{
 			name: "fail_no_id",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     []account{{}},
 				batchSize:   200,
@@ -1,7 +1,10 @@
 package salesforce
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -10,12 +13,29 @@ import (
 type configuration struct {
 	compressionHeaders           bool // compress request and response if true to save bandwidth
 	apiVersion                   string
+	negotiateAPIVersion          bool // use the newest org version up to apiVersion
 	batchSizeMax                 int
 	bulkBatchSizeMax             int
 	httpClient                   *http.Client      // HTTP client (created internally)
 	roundTripper                 http.RoundTripper // Custom round tripper
 	shouldValidateAuthentication bool              // Validate session on client creation
 	httpTimeout                  time.Duration     // HTTP client timeout
+	tokenStore                   TokenStore        // Persists session tokens across restarts
+	devicePrompt                 func(DeviceAuthorization)
+	sessionLifetime              time.Duration     // Refresh proactively before the session expires
+	jwtAudienceOverride          string            // aud claim of the JWT bearer assertion
+	jwtExpiration                time.Duration     // lifetime of the JWT bearer assertion
+	authFlow                     AuthFlowType      // flow forced by WithAuthFlow
+	clientCertificates           []tls.Certificate // presented for mutual TLS
+	retryPolicy                  RetryPolicy       // retries transient request failures
+	apiUsage                     *apiUsageTracker  // latest Sforce-Limit-Info usage
+	apiUsageHook                 func(APIUsage)    // called whenever a response reports usage
+	apiUsageSoftLimit            float64           // fraction of the daily limit that fails fast
+	restRateLimiter              *RateLimiter      // limits REST requests, may be shared
+	bulkRateLimiter              *RateLimiter      // limits Bulk API requests, may be shared
+	logger                       *slog.Logger      // nil discards the logs
+	middleware                   []Middleware      // wraps every request, first is outermost
+	tracer                       Tracer            // nil disables tracing
 }
 
 // setDefaults sets the default configuration values
@@ -27,6 +47,31 @@ func (c *configuration) setDefaults() {
 	c.bulkBatchSizeMax = bulkBatchSizeMax
 	c.httpTimeout = 0    // Default to no timeout (can be set via WithHTTPTimeout option)
 	c.roundTripper = nil // No custom round tripper by default
+	c.jwtExpiration = JwtExpirationTime
+	c.retryPolicy = RetryPolicy{MaxAttempts: 1} // no retries unless configured
+	c.apiUsage = &apiUsageTracker{}
+}
+
+// newConfiguration applies the options on top of the defaults and builds the HTTP client
+func newConfiguration(options ...Option) (*configuration, error) {
+	config := &configuration{}
+	config.setDefaults()
+
+	for _, option := range options {
+		if err := option(config); err != nil {
+			return nil, fmt.Errorf("configuration error: %w", err)
+		}
+	}
+
+	if len(config.clientCertificates) > 0 && config.roundTripper != nil {
+		if _, ok := config.roundTripper.(*http.Transport); !ok {
+			return nil, errors.New("configuration error: client certificates require " +
+				"the round tripper to be an *http.Transport")
+		}
+	}
+
+	config.configureHttpClient()
+	return config, nil
 }
 
 func (c *configuration) configureHttpClient() {
@@ -38,17 +83,38 @@ func (c *configuration) configureHttpClient() {
 				MaxIdleConns:       httpDefaultMaxIdleConnections,
 				IdleConnTimeout:    httpDefaultIdleConnTimeout,
 				DisableCompression: false,
+				TLSClientConfig:    c.clientTLSConfig(nil),
 			},
 		}
 	} else {
+		transport := c.roundTripper
+		if custom, ok := transport.(*http.Transport); ok && len(c.clientCertificates) > 0 {
+			// clone so the caller's transport is left without our certificates
+			custom = custom.Clone()
+			custom.TLSClientConfig = c.clientTLSConfig(custom.TLSClientConfig)
+			transport = custom
+		}
 		// Use custom round tripper with configured timeout
 		c.httpClient = &http.Client{
-			Transport: c.roundTripper,
+			Transport: transport,
 			Timeout:   c.httpTimeout,
 		}
 	}
 }
 
+// clientTLSConfig adds the client certificates to base, returns base when there are none
+func (c *configuration) clientTLSConfig(base *tls.Config) *tls.Config {
+	if len(c.clientCertificates) == 0 {
+		return base
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, c.clientCertificates...)
+	return tlsConfig
+}
+
 // Option is a functional configuration option that can return an error
 type Option func(*configuration) error
 
@@ -71,6 +137,15 @@ func WithAPIVersion(version string) Option {
 	}
 }
 
+// WithAPIVersionNegotiation sets whether Init picks the newest API version that both the org
+// and the library support. A version set with WithAPIVersion becomes the newest allowed.
+func WithAPIVersionNegotiation(negotiate bool) Option {
+	return func(c *configuration) error {
+		c.negotiateAPIVersion = negotiate
+		return nil
+	}
+}
+
 // WithBatchSizeMax sets the maximum batch size for collections
 func WithBatchSizeMax(size int) Option {
 	return func(c *configuration) error {
@@ -104,6 +179,29 @@ func WithRoundTripper(rt http.RoundTripper) Option {
 	}
 }
 
+// WithClientCertificate presents the certificate for mutual TLS on both the OAuth and API
+// endpoints. A custom round tripper must be an *http.Transport, it is cloned with the certificate.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *configuration) error {
+		if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+			return errors.New("client certificate must contain a certificate and a private key")
+		}
+		c.clientCertificates = append(c.clientCertificates, cert)
+		return nil
+	}
+}
+
+// WithClientCertificatePEM is WithClientCertificate for a PEM encoded certificate and key
+func WithClientCertificatePEM(certPEM []byte, keyPEM []byte) Option {
+	return func(c *configuration) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("loading client certificate: %w", err)
+		}
+		return WithClientCertificate(cert)(c)
+	}
+}
+
 // WithHTTPTimeout sets the HTTP client timeout duration
 func WithHTTPTimeout(timeout time.Duration) Option {
 	return func(c *configuration) error {
@@ -115,6 +213,103 @@ func WithHTTPTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithRetryPolicy retries requests that fail with a transient error. Only requests that are
+// idempotent, or marked with RetryableContext, are sent again.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *configuration) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+// WithAPIUsageHook calls hook with the org's API usage after every response that reports it.
+// It runs on the request goroutine and should return quickly.
+func WithAPIUsageHook(hook func(APIUsage)) Option {
+	return func(c *configuration) error {
+		if hook == nil {
+			return errors.New("API usage hook cannot be nil")
+		}
+		c.apiUsageHook = hook
+		return nil
+	}
+}
+
+// WithAPIUsageSoftLimit makes requests fail with an *APIUsageLimitError once the org's usage
+// reaches the fraction of its daily limit, e.g. 0.8, leaving headroom for other integrations
+func WithAPIUsageSoftLimit(fraction float64) Option {
+	return func(c *configuration) error {
+		if fraction <= 0 || fraction > 1 {
+			return errors.New("API usage soft limit must be greater than 0 and at most 1")
+		}
+		c.apiUsageSoftLimit = fraction
+		return nil
+	}
+}
+
+// WithRateLimiter limits the REST API requests, including their retries. Pass the same
+// limiter to several clients of an org to limit them together.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *configuration) error {
+		if limiter == nil {
+			return errors.New("rate limiter cannot be nil")
+		}
+		c.restRateLimiter = limiter
+		return nil
+	}
+}
+
+// WithBulkRateLimiter limits the Bulk API requests separately from the REST API requests
+func WithBulkRateLimiter(limiter *RateLimiter) Option {
+	return func(c *configuration) error {
+		if limiter == nil {
+			return errors.New("bulk rate limiter cannot be nil")
+		}
+		c.bulkRateLimiter = limiter
+		return nil
+	}
+}
+
+// WithLogger logs every request at debug level, and session refreshes and bulk job state
+// changes at info level. Tokens and credentials are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *configuration) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithMiddleware wraps every request to the Salesforce API with the middleware. The first
+// middleware is the outermost, options used more than once append to the chain.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *configuration) error {
+		for _, m := range middleware {
+			if m == nil {
+				return errors.New("middleware cannot be nil")
+			}
+		}
+		c.middleware = append(c.middleware, middleware...)
+		return nil
+	}
+}
+
+// WithTracer opens a span for every public method, with child spans for each HTTP request,
+// batch and bulk job poll
+func WithTracer(tracer Tracer) Option {
+	return func(c *configuration) error {
+		if tracer == nil {
+			return errors.New("tracer cannot be nil")
+		}
+		c.tracer = tracer
+		return nil
+	}
+}
+
 // WithValidateAuthentication sets whether to validate the authentication session on client creation
 func WithValidateAuthentication(validate bool) Option {
 	return func(c *configuration) error {
@@ -122,3 +317,78 @@ func WithValidateAuthentication(validate bool) Option {
 		return nil
 	}
 }
+
+// WithTokenStore sets the store used to load and persist session tokens
+func WithTokenStore(store TokenStore) Option {
+	return func(c *configuration) error {
+		if store == nil {
+			return errors.New("token store cannot be nil")
+		}
+		c.tokenStore = store
+		return nil
+	}
+}
+
+// WithDeviceFlow enables the OAuth device flow when Creds has only Domain and ConsumerKey.
+// prompt receives the user code and verification URL to show to the user.
+func WithDeviceFlow(prompt func(DeviceAuthorization)) Option {
+	return func(c *configuration) error {
+		if prompt == nil {
+			return errors.New("device flow prompt cannot be nil")
+		}
+		c.devicePrompt = prompt
+		return nil
+	}
+}
+
+// WithSessionLifetime enables refreshing the session before it expires. lifetime should match
+// the org's session timeout, it is used when the token can't be introspected.
+func WithSessionLifetime(lifetime time.Duration) Option {
+	return func(c *configuration) error {
+		if lifetime <= 0 {
+			return errors.New("session lifetime must be greater than 0")
+		}
+		c.sessionLifetime = lifetime
+		return nil
+	}
+}
+
+// WithJWTAudience sets the aud claim of the JWT bearer assertion, e.g. https://test.salesforce.com
+// or a My Domain login URL. By default it is guessed from Creds.Domain.
+func WithJWTAudience(audience string) Option {
+	return func(c *configuration) error {
+		if audience == "" {
+			return errors.New("JWT audience cannot be empty")
+		}
+		c.jwtAudienceOverride = audience
+		return nil
+	}
+}
+
+// WithJWTExpiration sets how long the JWT bearer assertion is valid for
+func WithJWTExpiration(expiration time.Duration) Option {
+	return func(c *configuration) error {
+		if expiration <= 0 {
+			return errors.New("JWT expiration must be greater than 0")
+		}
+		c.jwtExpiration = expiration
+		return nil
+	}
+}
+
+// WithAuthFlow forces Init to use the given flow instead of picking one from the Creds fields.
+// Init returns a *CredsError listing the missing and conflicting fields for that flow.
+func WithAuthFlow(authFlow AuthFlowType) Option {
+	return func(c *configuration) error {
+		switch authFlow {
+		case AuthFlowUsernamePassword, AuthFlowClientCredentials, AuthFlowAccessToken,
+			AuthFlowJWT, AuthFlowRefreshToken, AuthFlowDevice:
+			c.authFlow = authFlow
+			return nil
+		case AuthFlowWebServer:
+			return errors.New("web server flow is started with NewWebServerFlow, not Init")
+		default:
+			return fmt.Errorf("unsupported auth flow: %v", authFlow)
+		}
+	}
+}
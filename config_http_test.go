@@ -1,7 +1,22 @@
 package salesforce
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -304,3 +319,158 @@ func TestWithValidateAuthentication(t *testing.T) {
 		})
 	}
 }
+
+type countingRoundTripper struct {
+	requests int
+	next     http.RoundTripper
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return c.next.RoundTrip(req)
+}
+
+func TestAuthenticationUsesConfiguredClient(t *testing.T) {
+	server, _ := setupTestServer(authentication{AccessToken: "1234"}, http.StatusOK)
+	defer server.Close()
+	creds := Creds{
+		Domain:         server.URL,
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+	}
+
+	roundTripper := &countingRoundTripper{next: http.DefaultTransport}
+	if _, err := Init(creds, WithRoundTripper(roundTripper)); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if roundTripper.requests != 1 {
+		t.Errorf("Init() sent %d requests through the round tripper, want 1", roundTripper.requests)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := InitWithContext(ctx, creds)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("InitWithContext() error = %v, want context.Canceled", err)
+	}
+}
+
+// newTestClientCertificate returns a self-signed client certificate and its PEM encoding
+func newTestClientCertificate(t *testing.T) (*x509.Certificate, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "go-salesforce integration"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return cert, certPEM, keyPEM
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	clientCert, certPEM, keyPEM := newTestClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	verifiedPaths := []string{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 1 {
+			verifiedPaths = append(verifiedPaths, r.URL.Path)
+		}
+		var body any = map[string]any{}
+		if r.URL.Path == "/services/oauth2/token" {
+			body = authentication{AccessToken: "1234", InstanceUrl: "https://" + r.Host}
+		}
+		data, _ := json.Marshal(body)
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake failures
+	server.StartTLS()
+	defer server.Close()
+	// trusts the server certificate only
+	serverTransport := server.Client().Transport.(*http.Transport)
+
+	creds := Creds{
+		Domain:         server.URL,
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+	}
+	sf, err := Init(
+		creds,
+		WithRoundTripper(serverTransport),
+		WithClientCertificatePEM(certPEM, keyPEM),
+	)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if _, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	wantPaths := []string{
+		"/services/oauth2/token",
+		"/services/data/" + apiVersion + "/limits",
+	}
+	if !reflect.DeepEqual(verifiedPaths, wantPaths) {
+		t.Errorf("requests with a client certificate = %v, want %v", verifiedPaths, wantPaths)
+	}
+	if len(serverTransport.TLSClientConfig.Certificates) != 0 {
+		t.Error("WithClientCertificate() modified the caller's transport")
+	}
+
+	if _, err := Init(creds, WithRoundTripper(serverTransport)); err == nil {
+		t.Error("Init() without a client certificate should fail the TLS handshake")
+	}
+
+	if _, err := Init(
+		creds,
+		WithRoundTripper(&countingRoundTripper{next: serverTransport}),
+		WithClientCertificatePEM(certPEM, keyPEM),
+	); err == nil {
+		t.Error("Init() with a client certificate and a custom round tripper should return an error")
+	}
+
+	config, err := newConfiguration(WithClientCertificatePEM(certPEM, keyPEM))
+	if err != nil {
+		t.Fatalf("newConfiguration() error = %v", err)
+	}
+	transport := config.httpClient.Transport.(*http.Transport)
+	if len(transport.TLSClientConfig.Certificates) != 1 {
+		t.Error("default transport is missing the client certificate")
+	}
+
+	if _, err := newConfiguration(WithClientCertificatePEM(certPEM, []byte("not a key"))); err == nil {
+		t.Error("WithClientCertificatePEM() with an invalid key should return an error")
+	}
+	if _, err := newConfiguration(WithClientCertificate(tls.Certificate{})); err == nil {
+		t.Error("WithClientCertificate() with an empty certificate should return an error")
+	}
+}
@@ -1,7 +1,9 @@
 package salesforce
 
 import (
+	"log/slog"
 	"testing"
+	"time"
 )
 
 func TestWithCompressionHeaders(t *testing.T) {
@@ -212,3 +214,203 @@ func TestConfigurationDefaults(t *testing.T) {
 		)
 	}
 }
+
+func TestWithSessionLifetime(t *testing.T) {
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "valid_lifetime",
+			lifetime: 2 * time.Hour,
+			wantErr:  false,
+		},
+		{
+			name:     "zero_lifetime",
+			lifetime: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configuration{}
+			config.setDefaults()
+
+			err := WithSessionLifetime(tt.lifetime)(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithSessionLifetime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && config.sessionLifetime != tt.lifetime {
+				t.Errorf("WithSessionLifetime() = %v, want %v", config.sessionLifetime, tt.lifetime)
+			}
+		})
+	}
+}
+
+func TestWithAuthFlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		authFlow AuthFlowType
+		wantErr  bool
+	}{
+		{
+			name:     "jwt",
+			authFlow: AuthFlowJWT,
+			wantErr:  false,
+		},
+		{
+			name:     "web_server",
+			authFlow: AuthFlowWebServer,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown",
+			authFlow: AuthFlowUnknown,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configuration{}
+			config.setDefaults()
+
+			err := WithAuthFlow(tt.authFlow)(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithAuthFlow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && config.authFlow != tt.authFlow {
+				t.Errorf("WithAuthFlow() = %v, want %v", config.authFlow, tt.authFlow)
+			}
+		})
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		wantErr bool
+	}{
+		{
+			name:    "default_policy",
+			policy:  DefaultRetryPolicy(),
+			wantErr: false,
+		},
+		{
+			name:    "zero_attempts",
+			policy:  RetryPolicy{},
+			wantErr: true,
+		},
+		{
+			name:    "no_base_delay",
+			policy:  RetryPolicy{MaxAttempts: 3, MaxDelay: time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "max_delay_below_base_delay",
+			policy:  RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Millisecond},
+			wantErr: true,
+		},
+		{
+			name:    "no_max_delay",
+			policy:  RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second},
+			wantErr: false,
+		},
+		{
+			name:    "negative_max_delay",
+			policy:  RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: -time.Second},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configuration{}
+			config.setDefaults()
+
+			err := WithRetryPolicy(tt.policy)(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithRetryPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && config.retryPolicy.MaxAttempts != tt.policy.MaxAttempts {
+				t.Errorf("WithRetryPolicy() = %v, want %v", config.retryPolicy, tt.policy)
+			}
+		})
+	}
+}
+
+func TestWithAPIUsageSoftLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		fraction float64
+		wantErr  bool
+	}{
+		{
+			name:     "valid_fraction",
+			fraction: 0.8,
+			wantErr:  false,
+		},
+		{
+			name:     "zero_fraction",
+			fraction: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "above_one",
+			fraction: 80,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configuration{}
+			config.setDefaults()
+
+			err := WithAPIUsageSoftLimit(tt.fraction)(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithAPIUsageSoftLimit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && config.apiUsageSoftLimit != tt.fraction {
+				t.Errorf("WithAPIUsageSoftLimit() = %v, want %v", config.apiUsageSoftLimit, tt.fraction)
+			}
+		})
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		logger  *slog.Logger
+		wantErr bool
+	}{
+		{
+			name:    "valid_logger",
+			logger:  slog.New(slog.DiscardHandler),
+			wantErr: false,
+		},
+		{
+			name:    "nil_logger",
+			logger:  nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configuration{}
+			config.setDefaults()
+
+			err := WithLogger(tt.logger)(&config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithLogger() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && config.logger != tt.logger {
+				t.Errorf("WithLogger() = %v, want %v", config.logger, tt.logger)
+			}
+		})
+	}
+}
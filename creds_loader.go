@@ -0,0 +1,149 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultEnvPrefix is the environment variable prefix used when LoadCredsFromEnv gets an empty prefix
+const DefaultEnvPrefix = "SF_"
+
+// validateLoadedCreds reports the flow Init will pick for the credentials, or an error when
+// they are not enough for any flow
+func validateLoadedCreds(creds Creds) (Creds, AuthFlowType, error) {
+	authFlow, err := validateCredsForFlow(creds, AuthFlowUnknown, false)
+	if err != nil {
+		return Creds{}, AuthFlowUnknown, err
+	}
+	return creds, authFlow, nil
+}
+
+// LoadCredsFromEnv reads Creds from environment variables named after the Creds fields,
+// e.g. SF_DOMAIN, SF_CONSUMER_KEY and SF_REFRESH_TOKEN for the default prefix.
+// SF_CONSUMER_RSA_PEM_FILE can point to the private key instead of SF_CONSUMER_RSA_PEM.
+func LoadCredsFromEnv(prefix string) (Creds, AuthFlowType, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	env := func(name string) string {
+		return strings.TrimSpace(os.Getenv(prefix + name))
+	}
+
+	creds := Creds{
+		Domain:                   env("DOMAIN"),
+		Username:                 env("USERNAME"),
+		Password:                 env("PASSWORD"),
+		SecurityToken:            env("SECURITY_TOKEN"),
+		ConsumerKey:              env("CONSUMER_KEY"),
+		ConsumerSecret:           env("CONSUMER_SECRET"),
+		ConsumerRSAPem:           env("CONSUMER_RSA_PEM"),
+		ConsumerRSAPemPassphrase: env("CONSUMER_RSA_PEM_PASSPHRASE"),
+		AccessToken:              env("ACCESS_TOKEN"),
+		RefreshToken:             env("REFRESH_TOKEN"),
+	}
+	if pemFile := env("CONSUMER_RSA_PEM_FILE"); pemFile != "" {
+		if creds.ConsumerRSAPem != "" {
+			return Creds{}, AuthFlowUnknown, fmt.Errorf(
+				"only one of %sCONSUMER_RSA_PEM and %sCONSUMER_RSA_PEM_FILE can be set",
+				prefix,
+				prefix,
+			)
+		}
+		data, err := afero.ReadFile(appFs, pemFile)
+		if err != nil {
+			return Creds{}, AuthFlowUnknown, fmt.Errorf("reading private key: %w", err)
+		}
+		creds.ConsumerRSAPem = string(data)
+	}
+	return validateLoadedCreds(creds)
+}
+
+// ParseSFDXAuthURL reads Creds from an SFDX auth URL in the form
+// force://<clientId>:<clientSecret>:<refreshToken>@<instanceUrl>, where the secret may be empty
+func ParseSFDXAuthURL(authURL string) (Creds, AuthFlowType, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(authURL), "force://")
+	if !ok {
+		return Creds{}, AuthFlowUnknown, errors.New("sfdx auth url must start with force://")
+	}
+	// refresh tokens can contain '@', the instance url cannot
+	at := strings.LastIndex(rest, "@")
+	if at == -1 {
+		return Creds{}, AuthFlowUnknown, errors.New("sfdx auth url is missing the instance url")
+	}
+	parts := strings.SplitN(rest[:at], ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return Creds{}, AuthFlowUnknown, errors.New(
+			"sfdx auth url must contain a client id, client secret and refresh token",
+		)
+	}
+
+	instanceURL := rest[at+1:]
+	if !strings.Contains(instanceURL, "://") {
+		instanceURL = "https://" + instanceURL
+	}
+	if u, err := url.Parse(instanceURL); err != nil || u.Host == "" {
+		return Creds{}, AuthFlowUnknown, fmt.Errorf(
+			"invalid instance url in sfdx auth url: %s",
+			rest[at+1:],
+		)
+	}
+
+	return validateLoadedCreds(Creds{
+		Domain:         instanceURL,
+		ConsumerKey:    parts[0],
+		ConsumerSecret: parts[1],
+		RefreshToken:   parts[2],
+	})
+}
+
+type sfOrgDisplayOutput struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Result  struct {
+		AccessToken string `json:"accessToken"`
+		InstanceUrl string `json:"instanceUrl"`
+		Username    string `json:"username"`
+		ClientId    string `json:"clientId"`
+		SfdxAuthUrl string `json:"sfdxAuthUrl"`
+	} `json:"result"`
+}
+
+// ParseSFOrgDisplay reads Creds from the output of `sf org display --verbose --json`.
+// The sfdx auth url is preferred since it allows refreshing, otherwise the access token is used.
+func ParseSFOrgDisplay(data []byte) (Creds, AuthFlowType, error) {
+	output := sfOrgDisplayOutput{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return Creds{}, AuthFlowUnknown, fmt.Errorf("parsing sf org display output: %w", err)
+	}
+	if output.Status != 0 {
+		return Creds{}, AuthFlowUnknown, fmt.Errorf(
+			"sf org display failed with status %d: %s",
+			output.Status,
+			output.Message,
+		)
+	}
+
+	if output.Result.SfdxAuthUrl != "" {
+		creds, authFlow, err := ParseSFDXAuthURL(output.Result.SfdxAuthUrl)
+		if err != nil {
+			return Creds{}, AuthFlowUnknown, err
+		}
+		creds.Username = output.Result.Username
+		return creds, authFlow, nil
+	}
+	if output.Result.AccessToken == "" || output.Result.InstanceUrl == "" {
+		return Creds{}, AuthFlowUnknown, errors.New(
+			"sf org display output has neither an sfdx auth url nor an access token",
+		)
+	}
+	return validateLoadedCreds(Creds{
+		Domain:      output.Result.InstanceUrl,
+		AccessToken: output.Result.AccessToken,
+	})
+}
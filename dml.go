@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
 )
@@ -56,8 +57,44 @@ func processSalesforceResponse(resp http.Response) ([]SalesforceResult, error) {
 	return results, nil
 }
 
+// doCollectionBatch sends one batch of records to the sObject Collections API
+func (sf *Salesforce) doCollectionBatch(
+	ctx context.Context,
+	recordCount int,
+	payload requestPayload,
+) (results []SalesforceResult, err error) {
+	ctx, span := sf.config.startSpan(ctx, SpanBatch,
+		Attribute{Key: AttrOperation, Value: payload.operation},
+		sObjectAttr(payload.sObject),
+		batchSizeAttr(recordCount),
+	)
+	defer endSpan(span, &err)
+
+	resp, err := doRequest(ctx, sf.auth, sf.config, payload)
+	if err != nil {
+		return nil, err
+	}
+	results, err = processSalesforceResponse(*resp)
+	if err != nil {
+		return nil, err
+	}
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+	span.SetAttributes(
+		Attribute{Key: AttrRecords, Value: len(results)},
+		Attribute{Key: AttrRecordsFailed, Value: failed},
+	)
+	return results, nil
+}
+
 func (sf *Salesforce) doBatchedRequestsForCollection(
 	ctx context.Context,
+	operation string,
+	sObjectName string,
 	method string,
 	url string,
 	batchSize int,
@@ -84,20 +121,19 @@ func (sf *Salesforce) doBatchedRequestsForCollection(
 			return SalesforceResults{Results: results}, err
 		}
 
-		resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-			method:   method,
-			uri:      url,
-			content:  jsonType,
-			body:     string(body),
-			compress: sf.config.compressionHeaders,
+		currentResults, err := sf.doCollectionBatch(ctx, len(batch), requestPayload{
+			method:    method,
+			uri:       url,
+			content:   jsonType,
+			body:      string(body),
+			compress:  sf.config.compressionHeaders,
+			retryable: method == http.MethodPatch, // update and upsert by id are idempotent
+			operation: operation,
+			sObject:   sObjectName,
 		})
 		if err != nil {
 			return SalesforceResults{Results: results}, err
 		}
-		currentResults, err := processSalesforceResponse(*resp)
-		if err != nil {
-			return SalesforceResults{Results: results}, err
-		}
 
 		results = append(results, currentResults...)
 	}
@@ -143,11 +179,13 @@ func (sf *Salesforce) doInsertOne(
 	}
 
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPost,
-		uri:      "/sobjects/" + sObjectName,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodPost,
+		uri:       "/sobjects/" + sObjectName,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		operation: OperationInsertOne,
+		sObject:   sObjectName,
 	})
 	if err != nil {
 		return SalesforceResult{}, err
@@ -155,8 +193,7 @@ func (sf *Salesforce) doInsertOne(
 
 	data, err := decodeResponseBody(resp)
 	if err != nil {
-		fmt.Println("Error decoding: ", err)
-		return SalesforceResult{}, err
+		return SalesforceResult{}, fmt.Errorf("decoding response: %w", err)
 	}
 
 	return data, nil
@@ -181,16 +218,20 @@ func (sf *Salesforce) doUpdateOne(ctx context.Context, sObjectName string, recor
 		return err
 	}
 
-	_, err = doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPatch,
-		uri:      "/sobjects/" + sObjectName + "/" + recordId,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodPatch,
+		uri:       "/sobjects/" + sObjectName + "/" + recordId,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		retryable: true, // writing the same field values again is idempotent
+		operation: OperationUpdateOne,
+		sObject:   sObjectName,
 	})
 	if err != nil {
 		return err
 	}
+	_ = resp.Body.Close() // the response has no content we need
 
 	return nil
 }
@@ -225,11 +266,14 @@ func (sf *Salesforce) doUpsertOne(
 	}
 
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodPatch,
-		uri:      "/sobjects/" + sObjectName + "/" + fieldName + "/" + externalIdValue,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+		method:    http.MethodPatch,
+		uri:       "/sobjects/" + sObjectName + "/" + fieldName + "/" + externalIdValue,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		retryable: true, // upserting by external id is idempotent
+		operation: OperationUpsertOne,
+		sObject:   sObjectName,
 	})
 	if err != nil {
 		return SalesforceResult{}, err
@@ -237,8 +281,7 @@ func (sf *Salesforce) doUpsertOne(
 
 	data, err := decodeResponseBody(resp)
 	if err != nil {
-		fmt.Println("Error decoding: ", err)
-		return SalesforceResult{}, err
+		return SalesforceResult{}, fmt.Errorf("decoding response: %w", err)
 	}
 
 	return data, nil
@@ -255,15 +298,18 @@ func (sf *Salesforce) doDeleteOne(ctx context.Context, sObjectName string, recor
 		return errors.New("salesforce id not found in object data")
 	}
 
-	_, err = doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   http.MethodDelete,
-		uri:      "/sobjects/" + sObjectName + "/" + recordId,
-		content:  jsonType,
-		compress: sf.config.compressionHeaders,
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodDelete,
+		uri:       "/sobjects/" + sObjectName + "/" + recordId,
+		content:   jsonType,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationDeleteOne,
+		sObject:   sObjectName,
 	})
 	if err != nil {
 		return err
 	}
+	_ = resp.Body.Close() // the response has no content we need
 
 	return nil
 }
@@ -283,13 +329,17 @@ func (sf *Salesforce) doInsertCollection(
 		recordMap[i]["attributes"] = map[string]string{"type": sObjectName}
 	}
 
-	return sf.doBatchedRequestsForCollection(
+	results, err := sf.doBatchedRequestsForCollection(
 		ctx,
+		OperationInsertCollection,
+		sObjectName,
 		http.MethodPost,
 		"/composite/sobjects/",
 		batchSize,
 		recordMap,
 	)
+	correlateResults(results.Results, records)
+	return results, err
 }
 
 func (sf *Salesforce) doUpdateCollection(
@@ -310,13 +360,17 @@ func (sf *Salesforce) doUpdateCollection(
 		}
 	}
 
-	return sf.doBatchedRequestsForCollection(
+	results, err := sf.doBatchedRequestsForCollection(
 		ctx,
+		OperationUpdateCollection,
+		sObjectName,
 		http.MethodPatch,
 		"/composite/sobjects/",
 		batchSize,
 		recordMap,
 	)
+	correlateResults(results.Results, records)
+	return results, err
 }
 
 func (sf *Salesforce) doUpsertCollection(
@@ -343,7 +397,17 @@ func (sf *Salesforce) doUpsertCollection(
 	}
 
 	uri := "/composite/sobjects/" + sObjectName + "/" + fieldName
-	return sf.doBatchedRequestsForCollection(ctx, http.MethodPatch, uri, batchSize, recordMap)
+	results, err := sf.doBatchedRequestsForCollection(
+		ctx,
+		OperationUpsertCollection,
+		sObjectName,
+		http.MethodPatch,
+		uri,
+		batchSize,
+		recordMap,
+	)
+	correlateResults(results.Results, records)
+	return results, err
 }
 
 func (sf *Salesforce) doDeleteCollection(
@@ -387,22 +451,23 @@ func (sf *Salesforce) doDeleteCollection(
 	results := []SalesforceResult{}
 
 	for i := range batchedIds {
-		resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-			method:   http.MethodDelete,
-			uri:      "/composite/sobjects/?ids=" + batchedIds[i] + "&allOrNone=false",
-			content:  jsonType,
-			compress: sf.config.compressionHeaders,
+		recordCount := strings.Count(batchedIds[i], ",") + 1
+		currentResults, err := sf.doCollectionBatch(ctx, recordCount, requestPayload{
+			method:    http.MethodDelete,
+			uri:       "/composite/sobjects/?ids=" + batchedIds[i] + "&allOrNone=false",
+			content:   jsonType,
+			compress:  sf.config.compressionHeaders,
+			operation: OperationDeleteCollection,
+			sObject:   sObjectName,
 		})
 		if err != nil {
-			return SalesforceResults{Results: results}, err
-		}
-		currentResults, err := processSalesforceResponse(*resp)
-		if err != nil {
+			correlateResults(results, records)
 			return SalesforceResults{Results: results}, err
 		}
 
 		results = append(results, currentResults...)
 	}
+	correlateResults(results, records)
 
 	for _, result := range results {
 		if !result.Success {
@@ -214,7 +214,7 @@ func Test_doBatchedRequestsForCollection(t *testing.T) {
 		{
 			name: "single_record",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				method:    http.MethodPost,
 				url:       "",
 				batchSize: 200,
@@ -233,7 +233,7 @@ func Test_doBatchedRequestsForCollection(t *testing.T) {
 		{
 			name: "multiple_batches",
 			args: args{
-				sf:        buildSalesforceStruct(&sfAuth),
+				sf:        buildSalesforceStruct(sfAuth),
 				method:    http.MethodPost,
 				url:       "",
 				batchSize: 1,
@@ -255,7 +255,7 @@ func Test_doBatchedRequestsForCollection(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:        buildSalesforceStruct(&badReqSfAuth),
+				sf:        buildSalesforceStruct(badReqSfAuth),
 				method:    http.MethodPost,
 				url:       "",
 				batchSize: 1,
@@ -271,7 +271,7 @@ func Test_doBatchedRequestsForCollection(t *testing.T) {
 		{
 			name: "salesforce_error",
 			args: args{
-				sf:        buildSalesforceStruct(&sfErrorSfAuth),
+				sf:        buildSalesforceStruct(sfErrorSfAuth),
 				method:    http.MethodPost,
 				url:       "",
 				batchSize: 1,
@@ -292,6 +292,8 @@ func Test_doBatchedRequestsForCollection(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := tt.args.sf.doBatchedRequestsForCollection(
 				t.Context(),
+				OperationInsertCollection,
+				"Account",
 				tt.args.method,
 				tt.args.url,
 				tt.args.batchSize,
@@ -338,7 +340,7 @@ func Test_doInsertOne(t *testing.T) {
 		{
 			name: "successful_insert",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record: account{
 					Name: "test account",
@@ -350,7 +352,7 @@ func Test_doInsertOne(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				record: account{
 					Name: "test account",
@@ -362,7 +364,7 @@ func Test_doInsertOne(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record:      "1",
 			},
@@ -408,7 +410,7 @@ func Test_doUpdateOne(t *testing.T) {
 		{
 			name: "successful_update",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record: account{
 					Id:   "1234",
@@ -420,7 +422,7 @@ func Test_doUpdateOne(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				record: account{
 					Id:   "1234",
@@ -432,7 +434,7 @@ func Test_doUpdateOne(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record:      "1",
 			},
@@ -481,7 +483,7 @@ func Test_doUpsertOne(t *testing.T) {
 		{
 			name: "successful_upsert",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				record: account{
@@ -495,7 +497,7 @@ func Test_doUpsertOne(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				record: account{
@@ -509,7 +511,7 @@ func Test_doUpsertOne(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				record:      "1",
@@ -560,7 +562,7 @@ func Test_doDeleteOne(t *testing.T) {
 		{
 			name: "successful_delete",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record: account{
 					Id: "1234",
@@ -571,7 +573,7 @@ func Test_doDeleteOne(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				record: account{
 					Id: "1234",
@@ -582,7 +584,7 @@ func Test_doDeleteOne(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				record:      "1",
 			},
@@ -630,7 +632,7 @@ func Test_doInsertCollection(t *testing.T) {
 		{
 			name: "successful_insert_collection",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -648,7 +650,7 @@ func Test_doInsertCollection(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -708,7 +710,7 @@ func Test_doUpdateCollection(t *testing.T) {
 		{
 			name: "successful_update_collection",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -728,7 +730,7 @@ func Test_doUpdateCollection(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -789,7 +791,7 @@ func Test_doUpsertCollection(t *testing.T) {
 		{
 			name: "successful_upsert_collection",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				records: []account{
@@ -810,7 +812,7 @@ func Test_doUpsertCollection(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				fieldName:   "ExternalId__c",
 				records:     "1",
@@ -864,6 +866,7 @@ func Test_doDeleteCollection(t *testing.T) {
 				Id:      "1234",
 				Errors:  []SalesforceErrorMessage{},
 				Success: true,
+				Index:   1,
 			},
 		},
 		HasSalesforceErrors: false,
@@ -906,7 +909,7 @@ func Test_doDeleteCollection(t *testing.T) {
 		{
 			name: "successful_delete_collection_single_batch",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -924,7 +927,7 @@ func Test_doDeleteCollection(t *testing.T) {
 		{
 			name: "successful_delete_collection_multi_batch",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -942,7 +945,7 @@ func Test_doDeleteCollection(t *testing.T) {
 		{
 			name: "bad_data",
 			args: args{
-				sf:          buildSalesforceStruct(&sfAuth),
+				sf:          buildSalesforceStruct(sfAuth),
 				sObjectName: "Account",
 				records:     "1",
 				batchSize:   200,
@@ -953,7 +956,7 @@ func Test_doDeleteCollection(t *testing.T) {
 		{
 			name: "bad_request",
 			args: args{
-				sf:          buildSalesforceStruct(&badReqSfAuth),
+				sf:          buildSalesforceStruct(badReqSfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -968,7 +971,7 @@ func Test_doDeleteCollection(t *testing.T) {
 		{
 			name: "salesforce_errors",
 			args: args{
-				sf:          buildSalesforceStruct(&sfErrorSfAuth),
+				sf:          buildSalesforceStruct(sfErrorSfAuth),
 				sObjectName: "Account",
 				records: []account{
 					{
@@ -9,12 +9,14 @@ require (
 	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/jszwec/csvutil v1.10.0
 	github.com/spf13/afero v1.14.0
+	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78
 	k8s.io/apimachinery v0.33.0
 )
 
 require (
 	github.com/go-logr/logr v1.4.2 // indirect
-	golang.org/x/text v0.23.0 // indirect
+	golang.org/x/crypto v0.48.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
 	k8s.io/klog/v2 v2.130.1 // indirect
 	k8s.io/utils v0.0.0-20241104100929-3ea5e8cea738 // indirect
 )
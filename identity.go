@@ -0,0 +1,123 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Identity describes the user and org behind the session, as returned by the identity service
+type Identity struct {
+	Id             string            `json:"id"`
+	UserId         string            `json:"user_id"`
+	OrganizationId string            `json:"organization_id"`
+	Username       string            `json:"username"`
+	DisplayName    string            `json:"display_name"`
+	NickName       string            `json:"nick_name"`
+	Email          string            `json:"email"`
+	FirstName      string            `json:"first_name"`
+	LastName       string            `json:"last_name"`
+	Locale         string            `json:"locale"`
+	Language       string            `json:"language"`
+	Timezone       string            `json:"timezone"`
+	UserType       string            `json:"user_type"`
+	Active         bool              `json:"active"`
+	Urls           map[string]string `json:"urls"`
+}
+
+// userInfo is the OpenID Connect form of the identity, used when the session has no identity url
+type userInfo struct {
+	Sub               string            `json:"sub"`
+	UserId            string            `json:"user_id"`
+	OrganizationId    string            `json:"organization_id"`
+	PreferredUsername string            `json:"preferred_username"`
+	Name              string            `json:"name"`
+	Nickname          string            `json:"nickname"`
+	Email             string            `json:"email"`
+	GivenName         string            `json:"given_name"`
+	FamilyName        string            `json:"family_name"`
+	Locale            string            `json:"locale"`
+	Language          string            `json:"language"`
+	Zoneinfo          string            `json:"zoneinfo"`
+	UserType          string            `json:"user_type"`
+	Active            bool              `json:"active"`
+	Urls              map[string]string `json:"urls"`
+}
+
+func (u userInfo) toIdentity() *Identity {
+	return &Identity{
+		Id:             u.Sub,
+		UserId:         u.UserId,
+		OrganizationId: u.OrganizationId,
+		Username:       u.PreferredUsername,
+		DisplayName:    u.Name,
+		NickName:       u.Nickname,
+		Email:          u.Email,
+		FirstName:      u.GivenName,
+		LastName:       u.FamilyName,
+		Locale:         u.Locale,
+		Language:       u.Language,
+		Timezone:       u.Zoneinfo,
+		UserType:       u.UserType,
+		Active:         u.Active,
+		Urls:           u.Urls,
+	}
+}
+
+// getIdentity calls an identity endpoint, which lives outside the versioned REST API
+func (sf *Salesforce) getIdentity(ctx context.Context, endpoint string) ([]byte, error) {
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodGet,
+		uri:       endpoint,
+		content:   jsonType,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationIdentity,
+		absolute:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+	return io.ReadAll(resp.Body)
+}
+
+// Identity returns the user and org behind the session. Sessions without an identity url,
+// such as ones created from an access token, are looked up through the userinfo endpoint.
+func (sf *Salesforce) Identity(ctx context.Context) (identity *Identity, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Identity")
+	defer endSpan(span, &err)
+
+	authErr := validateAuth(*sf)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	sf.auth.mu.RLock()
+	identityUrl := sf.auth.Id
+	sf.auth.mu.RUnlock()
+
+	if identityUrl == "" {
+		body, err := sf.getIdentity(ctx, sf.auth.InstanceUrl+"/services/oauth2/userinfo")
+		if err != nil {
+			return nil, err
+		}
+		info := userInfo{}
+		if err := json.Unmarshal(body, &info); err != nil {
+			return nil, err
+		}
+		return info.toIdentity(), nil
+	}
+
+	body, err := sf.getIdentity(ctx, identityUrl)
+	if err != nil {
+		return nil, err
+	}
+	identity = &Identity{}
+	if err := json.Unmarshal(body, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
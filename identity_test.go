@@ -0,0 +1,178 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSalesforce_Identity(t *testing.T) {
+	identity := Identity{
+		UserId:         "005000000000001",
+		OrganizationId: "00D000000000001",
+		Username:       "integration@example.com",
+		DisplayName:    "Integration User",
+		Locale:         "en_US",
+		Timezone:       "America/Los_Angeles",
+		UserType:       "STANDARD",
+		Active:         true,
+		Urls: map[string]string{
+			"rest": "https://example.my.salesforce.com/services/data/v{version}/",
+		},
+	}
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any
+		switch {
+		case r.URL.Path == "/services/oauth2/token":
+			tokenRequests++
+			body = authentication{AccessToken: "refreshed"}
+		case r.Header.Get("Authorization") == "Bearer expired":
+			w.WriteHeader(http.StatusForbidden)
+			body = "Bad_OAuth_Token"
+		case r.URL.Path == "/id/00D000000000001/005000000000001":
+			body = identity
+		case r.URL.Path == "/services/oauth2/userinfo":
+			body = userInfo{
+				UserId:            identity.UserId,
+				OrganizationId:    identity.OrganizationId,
+				PreferredUsername: identity.Username,
+				Name:              identity.DisplayName,
+				Locale:            identity.Locale,
+				Zoneinfo:          identity.Timezone,
+				UserType:          identity.UserType,
+				Active:            identity.Active,
+				Urls:              identity.Urls,
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			body = "not found"
+		}
+		data, _ := json.Marshal(body)
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name              string
+		auth              *authentication
+		want              *Identity
+		wantTokenRequests int
+		wantErr           bool
+	}{
+		{
+			name: "identity_url",
+			auth: &authentication{
+				AccessToken: "1234",
+				InstanceUrl: server.URL,
+				Id:          server.URL + "/id/00D000000000001/005000000000001",
+			},
+			want: &identity,
+		},
+		{
+			name: "userinfo_without_identity_url",
+			auth: &authentication{
+				AccessToken: "1234",
+				InstanceUrl: server.URL,
+				grantType:   grantTypeAccessToken,
+			},
+			want: &identity,
+		},
+		{
+			name: "refresh_expired_token",
+			auth: &authentication{
+				AccessToken: "expired",
+				InstanceUrl: server.URL,
+				Id:          server.URL + "/id/00D000000000001/005000000000001",
+				grantType:   grantTypeClientCredentials,
+			},
+			want:              &identity,
+			wantTokenRequests: 1,
+		},
+		{
+			name: "expired_access_token",
+			auth: &authentication{
+				AccessToken: "expired",
+				InstanceUrl: server.URL,
+				grantType:   grantTypeAccessToken,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown_identity_url",
+			auth: &authentication{
+				AccessToken: "1234",
+				InstanceUrl: server.URL,
+				Id:          server.URL + "/id/missing",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "not_authenticated",
+			auth:    &authentication{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRequests = 0
+			sf := buildSalesforceStruct(tt.auth)
+			got, err := sf.Identity(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Identity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Identity() = %v, want %v", got, tt.want)
+			}
+			if tokenRequests != tt.wantTokenRequests {
+				t.Errorf("Identity() token requests = %d, want %d", tokenRequests, tt.wantTokenRequests)
+			}
+		})
+	}
+}
+
+func TestSalesforce_Identity_requestPipeline(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("Sforce-Call-Options")
+		w.WriteHeader(http.StatusNotFound)
+		if _, err := w.Write([]byte(`{"error":"not_found","error_description":"no such user"}`)); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{
+		AccessToken: "1234",
+		InstanceUrl: server.URL,
+		Id:          server.URL + "/id/missing",
+	})
+	var operations []string
+	record := func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			operations = append(operations, req.Operation)
+			return next(ctx, req)
+		}
+	}
+	if err := WithMiddleware(record)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := sf.Identity(WithCallOptions(t.Context(), CallClientId("partner")))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound ||
+		!apiErr.HasErrorCode("not_found") {
+		t.Errorf("Identity() error = %v, want an *APIError with status 404", err)
+	}
+	if !reflect.DeepEqual(operations, []string{OperationIdentity}) {
+		t.Errorf("middleware operations = %v, want [%s]", operations, OperationIdentity)
+	}
+	if gotHeader != "client=partner" {
+		t.Errorf("Sforce-Call-Options header = %q, want client=partner", gotHeader)
+	}
+}
@@ -23,6 +23,7 @@ type bulkJobQueryIterator struct {
 	Locator         string `json:"Sforce-Locator"`
 	auth            *authentication
 	uri             string
+	jobId           string
 	err             error
 	reader          io.ReadCloser
 	config          *configuration
@@ -39,6 +40,7 @@ func (sf *Salesforce) newBulkJobQueryIterator(
 	return &bulkJobQueryIterator{
 		auth:   sf.auth,
 		uri:    "/jobs/query/" + bulkJobId + "/results",
+		jobId:  bulkJobId,
 		config: sf.config,
 	}, nil
 }
@@ -59,10 +61,12 @@ func (it *bulkJobQueryIterator) Next(ctx context.Context) bool {
 		it.auth,
 		it.config,
 		requestPayload{
-			method:   http.MethodGet,
-			uri:      uri,
-			content:  jsonType,
-			compress: it.config.compressionHeaders,
+			method:    http.MethodGet,
+			uri:       uri,
+			content:   jsonType,
+			compress:  it.config.compressionHeaders,
+			operation: OperationGetBulkQueryResults,
+			jobId:     it.jobId,
 		},
 	)
 	if err != nil {
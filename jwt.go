@@ -0,0 +1,109 @@
+package salesforce
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/youmark/pkcs8"
+)
+
+// signingMethodRS256Signer signs JWTs with RS256 through a crypto.Signer, so the private key
+// can live in a signing agent or hardware module instead of process memory
+type signingMethodRS256Signer struct{}
+
+var jwtSigningMethodRS256Signer = &signingMethodRS256Signer{}
+
+func (m *signingMethodRS256Signer) Alg() string {
+	return jwt.SigningMethodRS256.Alg()
+}
+
+func (m *signingMethodRS256Signer) Sign(signingString string, key any) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, jwt.ErrInvalidKeyType
+	}
+	digest := sha256.Sum256([]byte(signingString))
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+func (m *signingMethodRS256Signer) Verify(signingString string, sig []byte, key any) error {
+	return jwt.SigningMethodRS256.Verify(signingString, sig, key)
+}
+
+// validateJWTSigner makes sure the signer can produce the RS256 signatures Salesforce accepts
+func validateJWTSigner(signer crypto.Signer) error {
+	if signer == nil {
+		return errors.New("jwt signer cannot be nil")
+	}
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return fmt.Errorf(
+			"salesforce jwt bearer flow only accepts RS256, got a %T signer",
+			signer.Public(),
+		)
+	}
+	return nil
+}
+
+// parseRSAPrivateKeyPEM parses an unencrypted PKCS#1/PKCS#8 or a passphrase protected
+// PKCS#8 RSA private key
+func parseRSAPrivateKeyPEM(keyPEM string, passphrase string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("ParseRSAPrivateKeyFromPEM: no PEM data found")
+	}
+
+	var signer crypto.Signer
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		if passphrase == "" {
+			return nil, errors.New("private key is encrypted but no passphrase was provided")
+		}
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("ParsePKCS8PrivateKey: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an RSA private key, got %T", key)
+		}
+		signer = rsaKey
+	case strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED"):
+		return nil, errors.New(
+			"legacy encrypted PEM keys are not supported, convert with 'openssl pkcs8 -topk8'",
+		)
+	default:
+		rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("ParseRSAPrivateKeyFromPEM: %w", err)
+		}
+		signer = rsaKey
+	}
+	return signer, nil
+}
+
+// jwtSignerFromCreds returns Creds.JWTSigner when set, otherwise the parsed ConsumerRSAPem
+func jwtSignerFromCreds(creds Creds) (crypto.Signer, error) {
+	if creds.JWTSigner != nil {
+		return creds.JWTSigner, nil
+	}
+	return parseRSAPrivateKeyPEM(creds.ConsumerRSAPem, creds.ConsumerRSAPemPassphrase)
+}
+
+// jwtAudience returns the login server expected in the aud claim. Without a configured audience
+// it is guessed from the domain, which doesn't work for every My Domain sandbox URL.
+func (conf *configuration) jwtAudience(domain string) string {
+	if conf.jwtAudienceOverride != "" {
+		return conf.jwtAudienceOverride
+	}
+	if strings.Contains(domain, "test.salesforce") || strings.Contains(domain, "sandbox") {
+		return "https://test.salesforce.com"
+	}
+	return "https://login.salesforce.com"
+}
@@ -0,0 +1,197 @@
+package salesforce
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/youmark/pkcs8"
+)
+
+// agentSigner only exposes crypto.Signer, like a key held by a signing agent
+type agentSigner struct {
+	key *rsa.PrivateKey
+}
+
+func (a agentSigner) Public() crypto.PublicKey {
+	return a.key.Public()
+}
+
+func (a agentSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return a.key.Sign(rand, digest, opts)
+}
+
+func Test_parseRSAPrivateKeyPEM(t *testing.T) {
+	sampleKey, _ := os.ReadFile("test/sample_key.pem")
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(sampleKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedDER, err := pkcs8.MarshalPrivateKey(rsaKey, []byte("passphrase"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: encryptedDER,
+	}))
+	pkcs8DER, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	pkcs8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}))
+	legacyPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED"},
+		Bytes:   []byte("encrypted"),
+	}))
+
+	tests := []struct {
+		name       string
+		keyPEM     string
+		passphrase string
+		wantErr    bool
+	}{
+		{
+			name:    "pkcs1_key",
+			keyPEM:  string(sampleKey),
+			wantErr: false,
+		},
+		{
+			name:    "pkcs8_key",
+			keyPEM:  pkcs8PEM,
+			wantErr: false,
+		},
+		{
+			name:       "encrypted_pkcs8_key",
+			keyPEM:     encryptedPEM,
+			passphrase: "passphrase",
+			wantErr:    false,
+		},
+		{
+			name:       "encrypted_pkcs8_key_wrong_passphrase",
+			keyPEM:     encryptedPEM,
+			passphrase: "wrong",
+			wantErr:    true,
+		},
+		{
+			name:    "encrypted_pkcs8_key_no_passphrase",
+			keyPEM:  encryptedPEM,
+			wantErr: true,
+		},
+		{
+			name:       "legacy_encrypted_pem",
+			keyPEM:     legacyPEM,
+			passphrase: "passphrase",
+			wantErr:    true,
+		},
+		{
+			name:    "not_pem",
+			keyPEM:  "not a key",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRSAPrivateKeyPEM(tt.keyPEM, tt.passphrase)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRSAPrivateKeyPEM() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !rsaKey.PublicKey.Equal(got.Public()) {
+				t.Errorf("parseRSAPrivateKeyPEM() returned a different key")
+			}
+		})
+	}
+}
+
+func TestInit_jwtSigner(t *testing.T) {
+	sampleKey, _ := os.ReadFile("test/sample_key.pem")
+	rsaKey, _ := jwt.ParseRSAPrivateKeyFromPEM(sampleKey)
+
+	var claims jwt.MapClaims
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		claims = jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(
+			r.PostForm.Get("assertion"),
+			claims,
+			func(*jwt.Token) (any, error) { return &rsaKey.PublicKey, nil },
+			jwt.WithValidMethods([]string{"RS256"}),
+			jwt.WithoutClaimsValidation(),
+		)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := json.Marshal(authentication{AccessToken: "1234"})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	sf, err := Init(
+		Creds{
+			Domain:      server.URL,
+			Username:    "user",
+			ConsumerKey: "key",
+			JWTSigner:   agentSigner{key: rsaKey},
+		},
+		WithJWTAudience("https://mycompany--dev.sandbox.my.salesforce.com"),
+		WithJWTExpiration(time.Minute),
+		WithValidateAuthentication(false),
+	)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if sf.GetAuthFlow() != AuthFlowJWT {
+		t.Errorf("Init() AuthFlow = %v, want %v", sf.GetAuthFlow(), AuthFlowJWT)
+	}
+	if claims["aud"] != "https://mycompany--dev.sandbox.my.salesforce.com" || claims["sub"] != "user" {
+		t.Errorf("Init() signed claims = %v", claims)
+	}
+}
+
+func Test_jwtAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		domain   string
+		want     string
+	}{
+		{
+			name:   "production",
+			domain: "https://mycompany.my.salesforce.com",
+			want:   "https://login.salesforce.com",
+		},
+		{
+			name:   "sandbox",
+			domain: "https://test.salesforce.com",
+			want:   "https://test.salesforce.com",
+		},
+		{
+			name:     "configured",
+			override: "https://mycompany--dev.my.salesforce.com",
+			domain:   "https://mycompany--dev.my.salesforce.com",
+			want:     "https://mycompany--dev.my.salesforce.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := getDefaultConfig(t)
+			config.jwtAudienceOverride = tt.override
+			if got := config.jwtAudience(tt.domain); got != tt.want {
+				t.Errorf("jwtAudience() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
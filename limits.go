@@ -0,0 +1,67 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Well-known keys of the Limits map
+const (
+	LimitDailyApiRequests              = "DailyApiRequests"
+	LimitDailyAsyncApexExecutions      = "DailyAsyncApexExecutions"
+	LimitDailyBulkApiBatches           = "DailyBulkApiBatches"
+	LimitDailyBulkV2QueryJobs          = "DailyBulkV2QueryJobs"
+	LimitDailyBulkV2QueryFileStorageMB = "DailyBulkV2QueryFileStorageMB"
+	LimitDailyStreamingApiEvents       = "DailyStreamingApiEvents"
+	LimitDataStorageMB                 = "DataStorageMB"
+	LimitFileStorageMB                 = "FileStorageMB"
+	LimitHourlyPublishedPlatformEvents = "HourlyPublishedPlatformEvents"
+	LimitSingleEmail                   = "SingleEmail"
+	LimitMassEmail                     = "MassEmail"
+)
+
+// Limit is the maximum and remaining allocation of one org limit
+type Limit struct {
+	Max       int `json:"Max"`
+	Remaining int `json:"Remaining"`
+}
+
+// Used returns how much of the limit has been consumed
+func (l Limit) Used() int {
+	return l.Max - l.Remaining
+}
+
+// Limits maps limit names, such as LimitDailyApiRequests, to their allocation
+type Limits map[string]Limit
+
+// Limits returns the org's limits and how much of each remains
+func (sf *Salesforce) Limits(ctx context.Context) (limits Limits, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Limits")
+	defer endSpan(span, &err)
+
+	authErr := validateAuth(*sf)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:    http.MethodGet,
+		uri:       "/limits",
+		content:   jsonType,
+		compress:  sf.config.compressionHeaders,
+		operation: OperationLimits,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+
+	limits = Limits{}
+	if err := json.NewDecoder(resp.Body).Decode(&limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
@@ -0,0 +1,67 @@
+package salesforce
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSalesforce_Limits(t *testing.T) {
+	body := map[string]any{
+		LimitDailyApiRequests: map[string]any{
+			"Max":                15000,
+			"Remaining":          14998,
+			"Ant Migration Tool": map[string]int{"Max": 0, "Remaining": 0},
+		},
+		LimitDailyBulkV2QueryJobs:     map[string]int{"Max": 10000, "Remaining": 9990},
+		LimitDataStorageMB:            map[string]int{"Max": 5, "Remaining": 5},
+		LimitDailyAsyncApexExecutions: map[string]int{"Max": 250000, "Remaining": 250000},
+	}
+	server, sfAuth := setupTestServer(body, http.StatusOK)
+	defer server.Close()
+
+	badServer, badAuth := setupTestServer("not limits", http.StatusOK)
+	defer badServer.Close()
+
+	tests := []struct {
+		name    string
+		sf      *Salesforce
+		want    Limits
+		wantErr bool
+	}{
+		{
+			name: "limits",
+			sf:   buildSalesforceStruct(sfAuth),
+			want: Limits{
+				LimitDailyApiRequests:         {Max: 15000, Remaining: 14998},
+				LimitDailyBulkV2QueryJobs:     {Max: 10000, Remaining: 9990},
+				LimitDataStorageMB:            {Max: 5, Remaining: 5},
+				LimitDailyAsyncApexExecutions: {Max: 250000, Remaining: 250000},
+			},
+		},
+		{
+			name:    "invalid_response",
+			sf:      buildSalesforceStruct(badAuth),
+			wantErr: true,
+		},
+		{
+			name:    "not_authenticated",
+			sf:      buildSalesforceStruct(&authentication{}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sf.Limits(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Limits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Limits() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && got[LimitDailyApiRequests].Used() != 2 {
+				t.Errorf("Limit.Used() = %d, want 2", got[LimitDailyApiRequests].Used())
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package salesforce
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// log returns the configured logger, or one that discards everything
+func (conf *configuration) log() *slog.Logger {
+	if conf.logger == nil {
+		return discardLogger
+	}
+	return conf.logger
+}
+
+// logRequest logs a finished request at debug level. Headers aren't logged so the
+// access token never ends up in the logs.
+func (conf *configuration) logRequest(
+	ctx context.Context,
+	payload requestPayload,
+	resp *http.Response,
+	err error,
+	latency time.Duration,
+	retries int,
+) {
+	logger := conf.log()
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("operation", payload.operation),
+		slog.String("method", payload.method),
+		slog.String("uri", payload.uri),
+		slog.Duration("latency", latency),
+		slog.Int("retries", retries),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("limit_info", resp.Header.Get(limitInfoHeader)),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "salesforce request", attrs...)
+}
+
+// redact hides a secret while still showing whether it was set
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// LogValue keeps the secrets out of the logs when Creds is passed to a slog.Logger
+func (creds Creds) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("domain", creds.Domain),
+		slog.String("username", creds.Username),
+		slog.String("password", redact(creds.Password)),
+		slog.String("security_token", redact(creds.SecurityToken)),
+		slog.String("consumer_key", creds.ConsumerKey),
+		slog.String("consumer_secret", redact(creds.ConsumerSecret)),
+		slog.String("consumer_rsa_pem", redact(creds.ConsumerRSAPem)),
+		slog.String("consumer_rsa_pem_passphrase", redact(creds.ConsumerRSAPemPassphrase)),
+		slog.String("access_token", redact(creds.AccessToken)),
+		slog.String("refresh_token", redact(creds.RefreshToken)),
+		slog.Bool("jwt_signer", creds.JWTSigner != nil),
+	)
+}
+
+// LogValue keeps the tokens out of the logs when a Token is passed to a slog.Logger
+func (t Token) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("access_token", redact(t.AccessToken)),
+		slog.String("refresh_token", redact(t.RefreshToken)),
+		slog.String("instance_url", t.InstanceUrl),
+		slog.String("id", t.Id),
+		slog.String("issued_at", t.IssuedAt),
+	)
+}
@@ -0,0 +1,124 @@
+package salesforce
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestLogger(t *testing.T) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})), buf
+}
+
+func TestSalesforce_logging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(limitInfoHeader, "api-usage=18/5000")
+		var body any = map[string]any{}
+		switch r.URL.Path {
+		case "/services/oauth2/token":
+			body = authentication{AccessToken: "new-secret-token"}
+		case "/services/data/" + apiVersion + "/jobs/ingest/750":
+			body = bulkJob{Id: "750", State: jobStateUploadComplete}
+		}
+		data, _ := json.Marshal(body)
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	logger, logs := newTestLogger(t)
+	sf := buildSalesforceStruct(&authentication{
+		AccessToken: "secret-token",
+		InstanceUrl: server.URL,
+		grantType:   grantTypeClientCredentials,
+		creds: Creds{
+			Domain:         server.URL,
+			ConsumerKey:    "key",
+			ConsumerSecret: "consumer-secret",
+		},
+	})
+	if err := WithLogger(logger)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if err := sf.Refresh(t.Context()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if err := sf.updateJobState(t.Context(), bulkJob{Id: "750"}, jobStateUploadComplete); err != nil {
+		t.Fatalf("updateJobState() error = %v", err)
+	}
+
+	entries := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	want := []map[string]any{
+		{"level": "DEBUG", "msg": "salesforce request", "method": "GET", "uri": "/limits"},
+		{"level": "INFO", "msg": "salesforce session refreshed", "grant_type": "client_credentials"},
+		{"level": "DEBUG", "msg": "salesforce request", "method": "PATCH", "uri": "/jobs/ingest/750"},
+		{"level": "INFO", "msg": "bulk job state changed", "job_id": "750", "state": "UploadComplete"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d: %s", len(entries), len(want), logs.String())
+	}
+	for i, wantEntry := range want {
+		for key, value := range wantEntry {
+			if entries[i][key] != value {
+				t.Errorf("log entry %d %s = %v, want %v", i, key, entries[i][key], value)
+			}
+		}
+	}
+	if entries[0]["status"] != float64(http.StatusOK) ||
+		entries[0]["limit_info"] != "api-usage=18/5000" ||
+		entries[0]["retries"] != float64(0) || entries[0]["latency"] == nil {
+		t.Errorf("request log entry = %v", entries[0])
+	}
+	for _, secret := range []string{"secret-token", "consumer-secret"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("logs contain %q: %s", secret, logs.String())
+		}
+	}
+}
+
+func TestCreds_LogValue(t *testing.T) {
+	logger, logs := newTestLogger(t)
+	logger.Info("init", "creds", Creds{
+		Domain:         "https://example.my.salesforce.com",
+		Username:       "user",
+		Password:       "hunter2",
+		SecurityToken:  "security-token",
+		ConsumerKey:    "key",
+		ConsumerSecret: "consumer-secret",
+		RefreshToken:   "refresh-token",
+	}, "token", Token{AccessToken: "access-token", InstanceUrl: "https://example.my.salesforce.com"})
+
+	for _, secret := range []string{
+		"hunter2",
+		"security-token",
+		"consumer-secret",
+		"refresh-token",
+		"access-token",
+	} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("logs contain %q: %s", secret, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), `"password":"[REDACTED]"`) ||
+		!strings.Contains(logs.String(), `"username":"user"`) {
+		t.Errorf("logs = %s", logs.String())
+	}
+}
@@ -0,0 +1,81 @@
+package salesforce
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation labels given to middleware, naming what a request does
+const (
+	OperationQuery               = "Query"
+	OperationInsertOne           = "InsertOne"
+	OperationUpdateOne           = "UpdateOne"
+	OperationUpsertOne           = "UpsertOne"
+	OperationDeleteOne           = "DeleteOne"
+	OperationInsertCollection    = "InsertCollection"
+	OperationUpdateCollection    = "UpdateCollection"
+	OperationUpsertCollection    = "UpsertCollection"
+	OperationDeleteCollection    = "DeleteCollection"
+	OperationInsertComposite     = "InsertComposite"
+	OperationUpdateComposite     = "UpdateComposite"
+	OperationUpsertComposite     = "UpsertComposite"
+	OperationDeleteComposite     = "DeleteComposite"
+	OperationCreateBulkJob       = "CreateBulkJob"
+	OperationUploadBulkJobData   = "UploadBulkJobData"
+	OperationUpdateBulkJobState  = "UpdateBulkJobState"
+	OperationGetBulkJob          = "GetBulkJob"
+	OperationGetBulkJobRecords   = "GetBulkJobRecords"
+	OperationGetBulkQueryResults = "GetBulkQueryResults"
+	OperationLimits              = "Limits"
+	OperationVersions            = "Versions"
+	OperationIdentity            = "Identity"
+	OperationValidateSession     = "ValidateSession"
+	OperationDoRequest           = "DoRequest"
+)
+
+// Request is a request to the Salesforce API as seen by a Middleware
+type Request struct {
+	Operation string      // one of the Operation constants
+	SObject   string      // SObject the request operates on, empty when there is none
+	JobId     string      // bulk job the request belongs to, empty when there is none
+	Method    string      // HTTP method
+	URI       string      // path relative to /services/data/<version>
+	Body      string      // uncompressed request body
+	Header    http.Header // sent on top of the library's headers, including on retries
+}
+
+// RequestHandler sends a request. It retries, refreshes the session and decompresses the
+// response as configured, so the response is the final one.
+type RequestHandler func(ctx context.Context, req *Request) (*http.Response, error)
+
+// Middleware wraps every request to the Salesforce API. It can change the request, look at the
+// response, or return without calling next.
+type Middleware func(next RequestHandler) RequestHandler
+
+func (payload requestPayload) toRequest() *Request {
+	header := http.Header{}
+	if payload.header != nil {
+		header = payload.header.Clone()
+	}
+	return &Request{
+		Operation: payload.operation,
+		SObject:   payload.sObject,
+		JobId:     payload.jobId,
+		Method:    payload.method,
+		URI:       payload.uri,
+		Body:      payload.body,
+		Header:    header,
+	}
+}
+
+// withRequest applies the changes a middleware made to req
+func (payload requestPayload) withRequest(req *Request) requestPayload {
+	payload.operation = req.Operation
+	payload.sObject = req.SObject
+	payload.jobId = req.JobId
+	payload.method = req.Method
+	payload.uri = req.URI
+	payload.body = req.Body
+	payload.header = req.Header
+	return payload
+}
@@ -0,0 +1,149 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	var gotHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.RequestURI, "/oauth2/token") {
+			body, _ := json.Marshal(authentication{AccessToken: "refreshed"})
+			if _, err := w.Write(body); err != nil {
+				panic(err)
+			}
+			return
+		}
+		gotHeaders = append(gotHeaders, r.Header.Get("X-Audit-Id"))
+		if r.Header.Get("Authorization") != "Bearer refreshed" {
+			body, _ := json.Marshal([]SalesforceErrorMessage{{ErrorCode: invalidSessionIdError}})
+			w.WriteHeader(http.StatusUnauthorized)
+			if _, err := w.Write(body); err != nil {
+				panic(err)
+			}
+			return
+		}
+		body, _ := json.Marshal(SalesforceResult{Id: "001", Success: true})
+		w.WriteHeader(http.StatusCreated)
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	var calls []string
+	var seen []Request
+	var statuses []int
+	outer := func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			calls = append(calls, "outer")
+			req.Header.Set("X-Audit-Id", "audit-1")
+			resp, err := next(ctx, req)
+			if resp != nil {
+				statuses = append(statuses, resp.StatusCode)
+			}
+			return resp, err
+		}
+	}
+	inner := func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			calls = append(calls, "inner")
+			seen = append(seen, *req)
+			return next(ctx, req)
+		}
+	}
+
+	sf := buildSalesforceStruct(&authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "expired",
+		grantType:   grantTypeClientCredentials,
+	})
+	if err := WithMiddleware(outer, inner)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := sf.InsertOne(t.Context(), "Account", map[string]any{"Name": "test"})
+	if err != nil {
+		t.Fatalf("InsertOne() error = %v", err)
+	}
+	if result.Id != "001" {
+		t.Errorf("InsertOne() = %v", result)
+	}
+
+	// the session refresh and its retry happen inside the chain, which runs once
+	if !slices.Equal(calls, []string{"outer", "inner"}) {
+		t.Errorf("middleware calls = %v, want [outer inner]", calls)
+	}
+	if !slices.Equal(gotHeaders, []string{"audit-1", "audit-1"}) {
+		t.Errorf("X-Audit-Id headers = %v, want the header on the request and its retry", gotHeaders)
+	}
+	if !slices.Equal(statuses, []int{http.StatusCreated}) {
+		t.Errorf("response statuses = %v, want [201]", statuses)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("inner middleware saw %d requests, want 1", len(seen))
+	}
+	req := seen[0]
+	if req.Operation != OperationInsertOne || req.SObject != "Account" ||
+		req.Method != http.MethodPost || req.URI != "/sobjects/Account" ||
+		!strings.Contains(req.Body, `"Name":"test"`) {
+		t.Errorf("request = %+v", req)
+	}
+}
+
+func TestWithMiddleware_shortCircuit(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	injected := errors.New("injected failure")
+	chaos := func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			if req.Operation == OperationGetBulkJob && req.JobId == "750" {
+				return nil, injected
+			}
+			return next(ctx, req)
+		}
+	}
+
+	sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+	if err := WithMiddleware(chaos)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := sf.GetJobResults(t.Context(), "750")
+	if !errors.Is(err, injected) {
+		t.Errorf("GetJobResults() error = %v, want %v", err, injected)
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests, want 0", requests)
+	}
+}
+
+func TestWithMiddleware_nil(t *testing.T) {
+	config := configuration{}
+	config.setDefaults()
+
+	if err := WithMiddleware(nil)(&config); err == nil {
+		t.Error("WithMiddleware(nil) error = nil, want an error")
+	}
+	noop := func(next RequestHandler) RequestHandler { return next }
+	if err := WithMiddleware(noop)(&config); err != nil {
+		t.Fatalf("WithMiddleware() error = %v", err)
+	}
+	if err := WithMiddleware(noop, noop)(&config); err != nil {
+		t.Fatalf("WithMiddleware() error = %v", err)
+	}
+	if len(config.middleware) != 3 {
+		t.Errorf("middleware chain has %d entries, want 3", len(config.middleware))
+	}
+}
@@ -27,10 +27,11 @@ func (sf *Salesforce) performQuery(ctx context.Context, query string, sObject an
 
 	for !queryResp.Done {
 		resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-			method:   http.MethodGet,
-			uri:      queryResp.NextRecordsUrl,
-			content:  jsonType,
-			compress: sf.config.compressionHeaders,
+			method:    http.MethodGet,
+			uri:       queryResp.NextRecordsUrl,
+			content:   jsonType,
+			compress:  sf.config.compressionHeaders,
+			operation: OperationQuery,
 		})
 		if err != nil {
 			return err
@@ -53,7 +54,7 @@ func (sf *Salesforce) performQuery(ctx context.Context, query string, sObject an
 		if !tempQueryResp.Done && tempQueryResp.NextRecordsUrl != "" {
 			queryResp.NextRecordsUrl = strings.TrimPrefix(
 				tempQueryResp.NextRecordsUrl,
-				"/services/data/"+apiVersion,
+				sf.config.dataPath(),
 			)
 		}
 	}
@@ -93,7 +93,7 @@ func Test_performQuery(t *testing.T) {
 		{
 			name: "http_error",
 			args: args{
-				sf:      buildSalesforceStruct(&badSfAuth),
+				sf:      buildSalesforceStruct(badSfAuth),
 				query:   "SELECT Id, Name FROM Account",
 				sObject: []account{},
 			},
@@ -103,7 +103,7 @@ func Test_performQuery(t *testing.T) {
 		{
 			name: "bad_response",
 			args: args{
-				sf:      buildSalesforceStruct(&badRespSfAuth),
+				sf:      buildSalesforceStruct(badRespSfAuth),
 				query:   "SELECT Id FROM Account",
 				sObject: []account{},
 			},
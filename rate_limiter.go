@@ -0,0 +1,128 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket combined with a limit on requests in flight. It is safe for
+// concurrent use, share one between the clients of an org to limit them together.
+type RateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens added per second, 0 for no rate limit
+	burst    float64
+	tokens   float64
+	last     time.Time
+	inFlight chan struct{} // nil for no in-flight limit
+}
+
+// NewRateLimiter allows requestsPerSecond on average with bursts of up to burst requests,
+// and at most maxInFlight requests at once. A zero requestsPerSecond or maxInFlight disables
+// that part of the limit.
+func NewRateLimiter(requestsPerSecond float64, burst int, maxInFlight int) (*RateLimiter, error) {
+	if requestsPerSecond < 0 || math.IsInf(requestsPerSecond, 0) || math.IsNaN(requestsPerSecond) {
+		return nil, errors.New("requests per second must be a finite number of at least 0")
+	}
+	if requestsPerSecond > 0 && burst < 1 {
+		return nil, errors.New("burst must be at least 1")
+	}
+	if maxInFlight < 0 {
+		return nil, errors.New("max in flight cannot be negative")
+	}
+	if requestsPerSecond == 0 && maxInFlight == 0 {
+		return nil, errors.New("rate limiter needs a rate or a max in flight")
+	}
+
+	limiter := &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	if maxInFlight > 0 {
+		limiter.inFlight = make(chan struct{}, maxInFlight)
+	}
+	return limiter, nil
+}
+
+// wait blocks until a token is available or the context is done
+func (l *RateLimiter) wait(ctx context.Context) error {
+	if l.rate == 0 {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// acquire waits for an in-flight slot and then for a token, so that a call canceled while
+// waiting for a slot doesn't use up a token. release must be called once the request is done.
+func (l *RateLimiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+			release = func() { <-l.inFlight }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// limitedBody holds a limiter slot while the response body is streamed. The slot is given
+// back once the body is read to the end or closed.
+type limitedBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func isBulkRequest(uri string) bool {
+	return strings.HasPrefix(uri, "/jobs/")
+}
+
+// rateLimiter returns the limiter for the request, nil when it is not limited
+func (conf *configuration) rateLimiter(payload requestPayload) *RateLimiter {
+	if isBulkRequest(payload.uri) {
+		return conf.bulkRateLimiter
+	}
+	return conf.restRateLimiter
+}
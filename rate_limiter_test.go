@@ -0,0 +1,216 @@
+package salesforce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestsPerSecond float64
+		burst             int
+		maxInFlight       int
+		wantErr           bool
+	}{
+		{name: "rate_and_in_flight", requestsPerSecond: 10, burst: 5, maxInFlight: 25},
+		{name: "in_flight_only", maxInFlight: 25},
+		{name: "rate_only", requestsPerSecond: 10, burst: 1},
+		{name: "no_limit", wantErr: true},
+		{name: "negative_rate", requestsPerSecond: -1, burst: 1, wantErr: true},
+		{name: "zero_burst", requestsPerSecond: 10, wantErr: true},
+		{name: "negative_in_flight", maxInFlight: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRateLimiter(tt.requestsPerSecond, tt.burst, tt.maxInFlight)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRateLimiter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_rate(t *testing.T) {
+	limiter, err := NewRateLimiter(100, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for range 6 {
+		release, err := limiter.acquire(t.Context())
+		if err != nil {
+			t.Fatalf("acquire() error = %v", err)
+		}
+		release()
+	}
+	// the first token is available right away, the next 5 take 10ms each
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("6 acquires at 100/s took %v, want at least 50ms", elapsed)
+	}
+}
+
+func TestRateLimiter_contextCanceled(t *testing.T) {
+	limiter, err := NewRateLimiter(0.1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := limiter.acquire(t.Context())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("acquire() waiting for a token error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	inFlightLimiter, _ := NewRateLimiter(0, 0, 1)
+	releaseSlot, _ := inFlightLimiter.acquire(t.Context())
+	defer releaseSlot()
+	ctx, cancel = context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := inFlightLimiter.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("acquire() waiting for a slot error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimiter_canceledWaitingForSlot(t *testing.T) {
+	limiter, err := NewRateLimiter(0.001, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := limiter.acquire(t.Context())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("acquire() waiting for a slot error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	release()
+
+	// the canceled call left the second token of the burst in the bucket
+	ctx, cancel = context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	release, err = limiter.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire() after a canceled call error = %v", err)
+	}
+	release()
+}
+
+func TestRateLimiter_sharedMaxInFlight(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if _, err := w.Write([]byte("{}")); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	limiter, err := NewRateLimiter(0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients := []*Salesforce{}
+	for range 2 {
+		sf := buildSalesforceStruct(&authentication{AccessToken: "1234", InstanceUrl: server.URL})
+		if err := WithRateLimiter(limiter)(sf.config); err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, sf)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sf := clients[i%len(clients)]
+			resp, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil)
+			if err != nil {
+				t.Errorf("DoRequest() error = %v", err)
+				return
+			}
+			_ = resp.Body.Close() // gives the in-flight slot back
+		}()
+	}
+	wg.Wait()
+	if got := maxInFlight.Load(); got != 2 {
+		t.Errorf("max requests in flight = %d, want 2", got)
+	}
+}
+
+func TestWithRateLimiter_streamedBody(t *testing.T) {
+	server, sfAuth := setupTestServer(map[string]any{"records": []any{}}, http.StatusOK)
+	defer server.Close()
+	limiter, err := NewRateLimiter(0, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := buildSalesforceStruct(sfAuth)
+	if err := WithRateLimiter(limiter)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil)
+	if err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	// the first body is still open, so the only slot is taken
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := sf.DoRequest(ctx, http.MethodGet, "/limits", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DoRequest() while a body is open error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil)
+	if err != nil {
+		t.Fatalf("DoRequest() after reading the body error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func Test_configurationRateLimiter(t *testing.T) {
+	restLimiter, _ := NewRateLimiter(10, 1, 0)
+	bulkLimiter, _ := NewRateLimiter(1, 1, 0)
+	config := getDefaultConfig(t)
+	if config.rateLimiter(requestPayload{uri: "/query"}) != nil {
+		t.Error("rateLimiter() without limiters should be nil")
+	}
+	for _, option := range []Option{WithRateLimiter(restLimiter), WithBulkRateLimiter(bulkLimiter)} {
+		if err := option(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if config.rateLimiter(requestPayload{uri: "/sobjects/Account"}) != restLimiter {
+		t.Error("rateLimiter() for a REST request should be the REST limiter")
+	}
+	if config.rateLimiter(requestPayload{uri: "/jobs/ingest/750/batches"}) != bulkLimiter {
+		t.Error("rateLimiter() for a Bulk request should be the Bulk limiter")
+	}
+}
@@ -4,60 +4,107 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/json"
-	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type requestPayload struct {
-	method   string
-	uri      string
-	content  string
-	body     string
-	retry    bool
-	compress bool
+	method    string
+	uri       string
+	content   string
+	body      string
+	retry     bool
+	compress  bool
+	retryable bool        // the body can be resent even though the method is not idempotent
+	operation string      // label given to middleware
+	sObject   string      // SObject the request operates on, if any
+	jobId     string      // bulk job the request belongs to, if any
+	header    http.Header // extra headers set by call options and middleware
+	// uri is relative to /services/data instead of the API version, e.g. to list versions
+	unversioned bool
+	absolute    bool // uri is a full url, e.g. the identity url
 }
 
+// doRequest sends the request through the configured middleware
 func doRequest(
 	ctx context.Context,
 	auth *authentication,
 	config *configuration,
 	payload requestPayload,
 ) (*http.Response, error) {
-	var reader io.Reader
-	var req *http.Request
-	var err error
-	endpoint := auth.InstanceUrl + "/services/data/" + config.apiVersion + payload.uri
-
-	if payload.body != "" {
-		if payload.compress {
-			reader, err = compress(payload.body)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			reader = strings.NewReader(payload.body)
-		}
-		req, err = http.NewRequestWithContext(ctx, payload.method, endpoint, reader)
-	} else {
-		req, err = http.NewRequestWithContext(ctx, payload.method, endpoint, nil)
-	}
+	header, err := callHeaders(ctx)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range payload.header {
+		if header == nil {
+			header = http.Header{}
+		}
+		header[key] = values
+	}
+	payload.header = header
 
-	req.Header.Set("User-Agent", "go-salesforce")
-	req.Header.Set("Content-Type", payload.content)
-	req.Header.Set("Accept", payload.content)
-	req.Header.Set("Authorization", "Bearer "+auth.AccessToken)
-	if payload.compress {
-		req.Header.Set("Content-Encoding", "gzip") // compress request
-		req.Header.Set("Accept-Encoding", "gzip")  // compress response
+	handler := func(ctx context.Context, req *Request) (*http.Response, error) {
+		return executeRequest(ctx, auth, config, payload.withRequest(req))
+	}
+	for i := len(config.middleware) - 1; i >= 0; i-- {
+		handler = config.middleware[i](handler)
+	}
+	return handler(ctx, payload.toRequest())
+}
+
+// executeRequest sends the request, with retries and a session refresh as needed
+func executeRequest(
+	ctx context.Context,
+	auth *authentication,
+	config *configuration,
+	payload requestPayload,
+) (*http.Response, error) {
+	endpoint := auth.InstanceUrl + config.dataPath() + payload.uri
+	if payload.unversioned {
+		endpoint = auth.InstanceUrl + servicesDataPath + payload.uri
+	}
+	if payload.absolute {
+		endpoint = payload.uri
+	}
+
+	if auth.isLoggedOut() {
+		return nil, ErrLoggedOut // covers iterators and jobs started before Logout
+	}
+	if err := config.checkAPIUsageSoftLimit(); err != nil {
+		return nil, err
+	}
+	if err := config.refreshSessionIfExpiring(ctx, auth); err != nil {
+		return nil, err
 	}
 
-	resp, err := config.httpClient.Do(req)
+	var resp *http.Response
+	var err error
+	start := time.Now()
+	attempt := 1
+	for ; ; attempt++ {
+		resp, err = sendRequest(ctx, auth, config, endpoint, payload)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 300 {
+			break
+		}
+		delay, retry := config.retryPolicy.retryDelay(ctx, attempt, payload, resp, err)
+		if !retry {
+			break
+		}
+		config.log().LogAttrs(ctx, slog.LevelDebug, "retrying salesforce request",
+			slog.String("method", payload.method),
+			slog.String("uri", payload.uri),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+		)
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+	config.logRequest(ctx, payload, resp, err, time.Since(start), attempt-1)
 	if err != nil {
 		return resp, err
 	}
@@ -70,39 +117,142 @@ func doRequest(
 
 	// salesforce does not guarantee that the response will be compressed
 	if resp.Header.Get("Content-Encoding") == "gzip" {
-		resp.Body, err = decompress(resp.Body)
+		body, err := decompress(resp.Body)
+		if err != nil {
+			_ = resp.Body.Close()
+			return resp, err
+		}
+		resp.Body = body
 	}
 
-	return resp, err
+	return resp, nil
 }
 
-func compress(body string) (io.Reader, error) {
-	var buf bytes.Buffer
-	gz := gzip.NewWriter(&buf)
-	if _, err := gz.Write([]byte(body)); err != nil {
-		return nil, err
+// sendRequest makes a single attempt of the request
+func sendRequest(
+	ctx context.Context,
+	auth *authentication,
+	config *configuration,
+	endpoint string,
+	payload requestPayload,
+) (resp *http.Response, err error) {
+	ctx, span := config.startSpan(ctx, SpanHTTPRequest,
+		Attribute{Key: AttrOperation, Value: payload.operation},
+		Attribute{Key: AttrHTTPMethod, Value: payload.method},
+		Attribute{Key: AttrURI, Value: payload.uri},
+	)
+	defer endSpan(span, &err)
+	if payload.sObject != "" {
+		span.SetAttributes(sObjectAttr(payload.sObject))
 	}
-	if err := gz.Close(); err != nil {
-		return nil, err
+	if payload.jobId != "" {
+		span.SetAttributes(jobIdAttr(payload.jobId))
 	}
-	return &buf, nil
-}
 
-func decompress(body io.ReadCloser) (io.ReadCloser, error) {
-	gzReader, err := gzip.NewReader(body)
+	// the limiter is taken first so a streamed body is never left unread
+	release := func() {}
+	if limiter := config.rateLimiter(payload); limiter != nil {
+		if release, err = limiter.acquire(ctx); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		// a response keeps the slot until its body is read or closed
+		if err != nil || resp == nil {
+			release()
+		} else {
+			resp.Body = &limitedBody{ReadCloser: resp.Body, release: release}
+		}
+	}()
+
+	var body io.Reader
+	if payload.body != "" {
+		body = strings.NewReader(payload.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, payload.method, endpoint, body)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = gzReader.Close() // Ignore error since we've read what we need
+	if payload.body != "" && payload.compress {
+		// the body is gzipped while it is sent, GetBody lets the transport start over
+		req.GetBody = func() (io.ReadCloser, error) {
+			return compress(payload.body), nil
+		}
+		req.Body, _ = req.GetBody()
+		req.ContentLength = -1
+	}
+
+	req.Header.Set("User-Agent", "go-salesforce")
+	req.Header.Set("Content-Type", payload.content)
+	req.Header.Set("Accept", payload.content)
+	req.Header.Set("Authorization", "Bearer "+auth.accessToken())
+	if payload.compress {
+		req.Header.Set("Content-Encoding", "gzip") // compress request
+		req.Header.Set("Accept-Encoding", "gzip")  // compress response
+	}
+	for key, values := range payload.header {
+		req.Header[key] = values
+	}
+
+	resp, err = config.httpClient.Do(req)
+	config.recordAPIUsage(resp)
+	if resp != nil {
+		span.SetAttributes(Attribute{Key: AttrHTTPStatus, Value: resp.StatusCode})
+	}
+	return resp, err
+}
+
+// compress gzips body while it is read, without holding the compressed body in memory.
+// Closing the reader stops the compression.
+func compress(body string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(writer)
+		// copied in chunks, writing the string at once would convert all of it to bytes
+		chunk := make([]byte, 32*1024)
+		var err error
+		for len(body) > 0 && err == nil {
+			n := copy(chunk, body)
+			body = body[n:]
+			_, err = gz.Write(chunk[:n])
+		}
+		if err == nil {
+			err = gz.Close()
+		}
+		writer.CloseWithError(err)
 	}()
+	return reader
+}
+
+// gzipBody decompresses a response body while it is read
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	_ = b.Reader.Close() // only reports errors already returned by Read
+	return b.body.Close()
+}
 
-	decompressed, err := io.ReadAll(gzReader)
+// decompress wraps a gzipped response body. Only the gzip header is read up front, the rest is
+// decompressed as the caller reads, so large bulk results are not held in memory.
+func decompress(body io.ReadCloser) (io.ReadCloser, error) {
+	gzReader, err := gzip.NewReader(body)
 	if err != nil {
 		return nil, err
 	}
+	return gzipBody{Reader: gzReader, body: body}, nil
+}
 
-	return io.NopCloser(bytes.NewReader(decompressed)), nil
+// sessionExpired reports whether the error means the access token is no longer valid
+func (payload requestPayload) sessionExpired(apiErr *APIError) bool {
+	if payload.operation == OperationIdentity {
+		// the identity service answers an expired token with 401 or 403 instead of INVALID_SESSION_ID
+		return apiErr.StatusCode == http.StatusUnauthorized ||
+			apiErr.StatusCode == http.StatusForbidden
+	}
+	return apiErr.HasErrorCode(invalidSessionIdError)
 }
 
 func processSalesforceError(
@@ -116,37 +266,26 @@ func processSalesforceError(
 	if err != nil {
 		return &resp, err
 	}
-	var sfErrors []SalesforceErrorMessage
-	err = json.Unmarshal(responseData, &sfErrors)
-	if err != nil {
-		return &resp, err
-	}
-	for _, sfError := range sfErrors {
-		if sfError.ErrorCode == invalidSessionIdError &&
-			!payload.retry { // only attempt to refresh the session once
-			err = refreshSession(auth)
-			if err != nil {
-				return &resp, err
-			}
-			newResp, err := doRequest(
-				ctx,
-				auth,
-				config,
-				requestPayload{
-					payload.method,
-					payload.uri,
-					payload.content,
-					payload.body,
-					true,
-					payload.compress,
-				},
-			)
-			if err != nil {
-				return &resp, err
-			}
-			return newResp, nil
+	apiErr := newAPIError(&resp, payload, responseData)
+	if payload.sessionExpired(apiErr) &&
+		!payload.retry { // only attempt to refresh the session once
+		var staleToken string
+		if resp.Request != nil {
+			staleToken = strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")
+		}
+		err = config.refreshSessionOnce(ctx, auth, staleToken)
+		if err != nil {
+			return &resp, err
+		}
+		retryPayload := payload
+		retryPayload.retry = true
+		newResp, err := executeRequest(ctx, auth, config, retryPayload)
+		if err != nil {
+			return &resp, err
 		}
+		return newResp, nil
 	}
 
-	return &resp, errors.New(string(responseData))
+	resp.Body = io.NopCloser(bytes.NewReader(responseData))
+	return &resp, apiErr
 }
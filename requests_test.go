@@ -1,14 +1,21 @@
 package salesforce
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_doRequest(t *testing.T) {
@@ -41,7 +48,7 @@ func Test_doRequest(t *testing.T) {
 		{
 			name: "make_generic_http_call_ok",
 			args: args{
-				auth: &sfAuth,
+				auth: sfAuth,
 				payload: requestPayload{
 					method:  http.MethodGet,
 					uri:     "",
@@ -55,7 +62,7 @@ func Test_doRequest(t *testing.T) {
 		{
 			name: "make_generic_http_call_bad_request",
 			args: args{
-				auth: &badSfAuth,
+				auth: badSfAuth,
 				payload: requestPayload{
 					method:  http.MethodGet,
 					uri:     "",
@@ -69,7 +76,7 @@ func Test_doRequest(t *testing.T) {
 		{
 			name: "handle_multiple_records_with_same_externalId_statusCode_300",
 			args: args{
-				auth: &authWith300Resp,
+				auth: authWith300Resp,
 				payload: requestPayload{
 					method:  http.MethodGet,
 					uri:     "/sobjects/Contact/ContactExternalId__c/Avng1",
@@ -83,7 +90,7 @@ func Test_doRequest(t *testing.T) {
 		{
 			name: "compression_headers",
 			args: args{
-				auth: &sfAuthCompressed,
+				auth: sfAuthCompressed,
 				payload: requestPayload{
 					method:   http.MethodGet,
 					uri:      "",
@@ -112,7 +119,7 @@ func Test_doRequest(t *testing.T) {
 }
 
 func Test_compression(t *testing.T) {
-	compressedResp, _ := compress("testRecord1")
+	compressedResp := compress("testRecord1")
 
 	type args struct {
 		body io.ReadCloser
@@ -240,7 +247,7 @@ func Test_processSalesforceError(t *testing.T) {
 			name: "process_500_error",
 			args: args{
 				resp:    exampleResp,
-				auth:    &badSfAuth,
+				auth:    badSfAuth,
 				payload: reqPayload,
 			},
 			want:    exampleResp.StatusCode,
@@ -254,7 +261,7 @@ func Test_processSalesforceError(t *testing.T) {
 					StatusCode: 400,
 					Body:       io.NopCloser(strings.NewReader(string(bodyInvalidSession))),
 				},
-				auth:    &sfAuthInvalidSession,
+				auth:    sfAuthInvalidSession,
 				payload: reqPayload,
 			},
 			want:    http.StatusOK,
@@ -268,7 +275,7 @@ func Test_processSalesforceError(t *testing.T) {
 					StatusCode: 400,
 					Body:       io.NopCloser(strings.NewReader(string(bodyInvalidSession))),
 				},
-				auth:    &sfAuthRefreshFail,
+				auth:    sfAuthRefreshFail,
 				payload: reqPayload,
 			},
 			want:    400,
@@ -309,3 +316,262 @@ func Test_processSalesforceError(t *testing.T) {
 		})
 	}
 }
+
+func Test_concurrentSessionRefresh(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.RequestURI, "/oauth2/token") {
+			tokenRequests.Add(1)
+			time.Sleep(20 * time.Millisecond) // keep the refresh in flight while others pile up
+			body, _ := json.Marshal(authentication{AccessToken: "refreshed"})
+			if _, err := w.Write(body); err != nil {
+				panic(err)
+			}
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer refreshed" {
+			body, _ := json.Marshal([]SalesforceErrorMessage{{ErrorCode: invalidSessionIdError}})
+			w.WriteHeader(http.StatusUnauthorized)
+			if _, err := w.Write(body); err != nil {
+				panic(err)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sf := buildSalesforceStruct(&authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "expired",
+		grantType:   grantTypeClientCredentials,
+	})
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sf.DoRequest(t.Context(), http.MethodGet, "/limits", nil)
+			errs <- err
+			if token := sf.GetAccessToken(); token != "expired" && token != "refreshed" {
+				errs <- errors.New("read half-updated token: " + token)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("DoRequest() error = %v", err)
+		}
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1", got)
+	}
+	if sf.GetAccessToken() != "refreshed" {
+		t.Errorf("GetAccessToken() = %v, want refreshed", sf.GetAccessToken())
+	}
+}
+
+func Test_sessionRefresh_leaderCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond) // outlasts the first caller's deadline
+		body, _ := json.Marshal(authentication{AccessToken: "refreshed"})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "old",
+		grantType:   grantTypeClientCredentials,
+	})
+
+	leaderCtx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error)
+	go func() {
+		leaderErr <- sf.config.refreshSessionOnce(leaderCtx, sf.auth, "old")
+	}()
+	time.Sleep(10 * time.Millisecond) // let the leader start the refresh
+
+	if err := sf.config.refreshSessionOnce(context.Background(), sf.auth, "old"); err != nil {
+		t.Errorf("waiter refreshSessionOnce() error = %v", err)
+	}
+	if err := <-leaderErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("leader refreshSessionOnce() error = %v, want context.DeadlineExceeded", err)
+	}
+	if sf.GetAccessToken() != "refreshed" {
+		t.Errorf("GetAccessToken() = %v, want refreshed", sf.GetAccessToken())
+	}
+}
+
+func Test_sessionLockPerClient(t *testing.T) {
+	busy := &authentication{AccessToken: "busy"}
+	other := &authentication{AccessToken: "other"}
+	busy.mu.Lock() // e.g. a refresh writing the new token
+	defer busy.mu.Unlock()
+
+	done := make(chan string)
+	go func() {
+		done <- other.accessToken()
+	}()
+	select {
+	case token := <-done:
+		if token != "other" {
+			t.Errorf("accessToken() = %v, want other", token)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("accessToken() blocked on the session of another client")
+	}
+}
+
+func Test_doRequest_streamsCompression(t *testing.T) {
+	record := strings.Repeat("a,b,c\n", 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gzReader, err := gzip.NewReader(r.Body)
+		if err != nil || r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(gzReader)
+		if err != nil || string(body) != record {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gzWriter := gzip.NewWriter(w)
+		if _, err := gzWriter.Write(body); err != nil {
+			panic(err)
+		}
+		if err := gzWriter.Close(); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+
+	resp, err := doRequest(t.Context(), sf.auth, sf.config, requestPayload{
+		method:   http.MethodPut,
+		uri:      "/jobs/ingest/750/batches",
+		content:  csvType,
+		body:     record,
+		compress: true,
+	})
+	if err != nil {
+		t.Fatalf("doRequest() error = %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body error = %v", err)
+	}
+	if string(got) != record {
+		t.Errorf("doRequest() body has %d bytes, want the %d bytes sent", len(got), len(record))
+	}
+}
+
+func Test_compress_close(t *testing.T) {
+	reader := compress(strings.Repeat("a", 1<<20))
+	if err := reader.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := reader.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Read() after Close error = %v, want io.ErrClosedPipe", err)
+	}
+}
+
+// benchmarkBody is a bulk upload sized body, large enough for buffering to show
+var benchmarkBody = strings.Repeat("Name,ExternalId__c,Description\ntest,12345,some text\n", 200000)
+
+// compressBuffered and decompressBuffered are the buffered approach, kept as a baseline
+func compressBuffered(body string) (io.Reader, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func decompressBuffered(body io.ReadCloser) (io.ReadCloser, error) {
+	gzReader, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := io.ReadAll(gzReader)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(decompressed)), nil
+}
+
+func BenchmarkCompress(b *testing.B) {
+	b.Run("streamed", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(benchmarkBody)))
+		for b.Loop() {
+			reader := compress(benchmarkBody)
+			if _, err := io.Copy(io.Discard, reader); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(benchmarkBody)))
+		for b.Loop() {
+			reader, err := compressBuffered(benchmarkBody)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, reader); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	compressed, err := compressBuffered(benchmarkBody)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := io.ReadAll(compressed)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarks := []struct {
+		name       string
+		decompress func(io.ReadCloser) (io.ReadCloser, error)
+	}{
+		{name: "streamed", decompress: decompress},
+		{name: "buffered", decompress: decompressBuffered},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(benchmarkBody)))
+			for b.Loop() {
+				body, err := bm.decompress(io.NopCloser(bytes.NewReader(data)))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(io.Discard, body); err != nil {
+					b.Fatal(err)
+				}
+				_ = body.Close()
+			}
+		})
+	}
+}
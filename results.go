@@ -0,0 +1,86 @@
+package salesforce
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ResultKeyer is implemented by records that carry their own key, such as an id from the
+// caller's system. The key is copied to the SalesforceResult of the record.
+type ResultKeyer interface {
+	ResultKey() string
+}
+
+// correlateResults sets the Index of each result, and its Key when the record at that index
+// implements ResultKeyer. records is the slice passed to the collection or composite call.
+func correlateResults(results []SalesforceResult, records any) {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		return
+	}
+	for i := range results {
+		results[i].Index = i
+		if i >= value.Len() {
+			continue
+		}
+		if keyer, ok := value.Index(i).Interface().(ResultKeyer); ok {
+			results[i].Key = keyer.ResultKey()
+		}
+	}
+}
+
+// alignBatchResults returns one result per record of a failed batch. A sub request that failed
+// as a whole, e.g. because an earlier one failed with allOrNone, has fewer results than records.
+func alignBatchResults(
+	results []SalesforceResult,
+	recordCount int,
+	statusCode int,
+) []SalesforceResult {
+	if len(results) == recordCount {
+		return results
+	}
+	aligned := make([]SalesforceResult, recordCount)
+	for i := range aligned {
+		aligned[i] = SalesforceResult{
+			Success: false,
+			Errors: []SalesforceErrorMessage{{
+				StatusCode: strconv.Itoa(statusCode),
+				Message: "the batch failed without a result for the record: " +
+					http.StatusText(statusCode),
+			}},
+		}
+	}
+	return aligned
+}
+
+// Failed returns the results of the records that were not saved
+func (results SalesforceResults) Failed() []SalesforceResult {
+	var failed []SalesforceResult
+	for _, result := range results.Results {
+		if !result.Success {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// SplitRecords splits the records passed to a collection or composite call by their result.
+// Records without a result, because the call stopped before their batch was sent, count as
+// failed so they can be retried.
+func SplitRecords[T any](records []T, results SalesforceResults) (succeeded []T, failed []T) {
+	saved := make([]bool, len(records))
+	for _, result := range results.Results {
+		if result.Success && result.Index >= 0 && result.Index < len(records) {
+			saved[result.Index] = true
+		}
+	}
+	for i, record := range records {
+		if saved[i] {
+			succeeded = append(succeeded, record)
+		} else {
+			failed = append(failed, record)
+		}
+	}
+	return succeeded, failed
+}
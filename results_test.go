@@ -0,0 +1,168 @@
+package salesforce
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type keyedAccount struct {
+	Name       string
+	ExternalId string `mapstructure:"-"`
+}
+
+func (a keyedAccount) ResultKey() string {
+	return a.ExternalId
+}
+
+func TestSalesforce_InsertCollection_correlatesResults(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		result := SalesforceResult{Id: "001", Success: true}
+		if requests == 2 {
+			result = SalesforceResult{
+				Errors: []SalesforceErrorMessage{{ErrorCode: "DUPLICATE_VALUE"}},
+			}
+		}
+		body, _ := json.Marshal([]SalesforceResult{result})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+
+	records := []keyedAccount{
+		{Name: "first", ExternalId: "a"},
+		{Name: "second", ExternalId: "b"},
+		{Name: "third", ExternalId: "c"},
+	}
+	results, err := sf.InsertCollection(t.Context(), "Account", records, 1)
+	if err != nil {
+		t.Fatalf("InsertCollection() error = %v", err)
+	}
+
+	for i, result := range results.Results {
+		if result.Index != i || result.Key != records[i].ExternalId {
+			t.Errorf("result %d Index = %d, Key = %q", i, result.Index, result.Key)
+		}
+	}
+	failed := results.Failed()
+	if len(failed) != 1 || failed[0].Key != "b" {
+		t.Errorf("Failed() = %v, want the result of record b", failed)
+	}
+	succeeded, failedRecords := SplitRecords(records, results)
+	if !reflect.DeepEqual(succeeded, []keyedAccount{records[0], records[2]}) ||
+		!reflect.DeepEqual(failedRecords, []keyedAccount{records[1]}) {
+		t.Errorf("SplitRecords() = %v, %v", succeeded, failedRecords)
+	}
+}
+
+func TestSplitRecords(t *testing.T) {
+	records := []string{"a", "b", "c", "d"}
+	tests := []struct {
+		name          string
+		results       SalesforceResults
+		wantSucceeded []string
+		wantFailed    []string
+	}{
+		{
+			name: "all_succeeded",
+			results: SalesforceResults{Results: []SalesforceResult{
+				{Success: true, Index: 0},
+				{Success: true, Index: 1},
+				{Success: true, Index: 2},
+				{Success: true, Index: 3},
+			}},
+			wantSucceeded: []string{"a", "b", "c", "d"},
+			wantFailed:    nil,
+		},
+		{
+			name: "some_failed",
+			results: SalesforceResults{Results: []SalesforceResult{
+				{Success: true, Index: 0},
+				{Success: false, Index: 1},
+				{Success: true, Index: 2},
+				{Success: false, Index: 3},
+			}},
+			wantSucceeded: []string{"a", "c"},
+			wantFailed:    []string{"b", "d"},
+		},
+		{
+			name: "missing_results_count_as_failed",
+			results: SalesforceResults{Results: []SalesforceResult{
+				{Success: true, Index: 0},
+				{Success: true, Index: 1},
+			}},
+			wantSucceeded: []string{"a", "b"},
+			wantFailed:    []string{"c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			succeeded, failed := SplitRecords(records, tt.results)
+			if !reflect.DeepEqual(succeeded, tt.wantSucceeded) {
+				t.Errorf("SplitRecords() succeeded = %v, want %v", succeeded, tt.wantSucceeded)
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("SplitRecords() failed = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func Test_processCompositeResponse_alignsHaltedBatches(t *testing.T) {
+	failedRecord := SalesforceResult{
+		Errors: []SalesforceErrorMessage{{ErrorCode: "REQUIRED_FIELD_MISSING"}},
+	}
+	body, _ := json.Marshal(compositeRequestResult{
+		CompositeResponse: []compositeSubRequestResult{
+			{
+				Body:           []SalesforceResult{failedRecord, failedRecord},
+				HttpStatusCode: http.StatusBadRequest,
+				ReferenceId:    "refObj0",
+			},
+			{
+				// a halted sub request has a single error instead of a result per record
+				Body:           []SalesforceResult{{}},
+				HttpStatusCode: http.StatusBadRequest,
+				ReferenceId:    "refObj1",
+			},
+		},
+	})
+	compReq := compositeRequest{
+		AllOrNone: true,
+		CompositeRequest: []compositeSubRequest{
+			{
+				Method: http.MethodPost,
+				Body:   sObjectCollection{Records: []map[string]any{{}, {}}},
+			},
+			{
+				Method: http.MethodDelete,
+				Url:    "/services/data/v63.0/composite/sobjects/?ids=001,002,003&allOrNone=true",
+			},
+		},
+	}
+
+	results, err := processCompositeResponse(
+		http.Response{Body: io.NopCloser(bytes.NewReader(body))},
+		compReq,
+	)
+	if err != nil {
+		t.Fatalf("processCompositeResponse() error = %v", err)
+	}
+	if len(results.Results) != 5 || !results.HasSalesforceErrors {
+		t.Fatalf("processCompositeResponse() = %v, want 5 failed results", results)
+	}
+	for i, result := range results.Results[2:] {
+		if result.Success || len(result.Errors) != 1 ||
+			result.Errors[0].StatusCode != "400" {
+			t.Errorf("halted result %d = %v", i, result)
+		}
+	}
+}
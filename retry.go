@@ -0,0 +1,209 @@
+package salesforce
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	requestLimitExceededError = "REQUEST_LIMIT_EXCEEDED"
+	unableToLockRowError      = "UNABLE_TO_LOCK_ROW"
+	serverUnavailableError    = "SERVER_UNAVAILABLE"
+)
+
+// RetryClassifier reports whether a failed attempt should be retried. resp is nil when the
+// request failed without a response, sfErrors holds the parsed Salesforce error body, if any.
+type RetryClassifier func(resp *http.Response, sfErrors []SalesforceErrorMessage, err error) bool
+
+// RetryPolicy controls how requests that fail with a transient error are retried
+type RetryPolicy struct {
+	MaxAttempts int             // total attempts including the first one
+	BaseDelay   time.Duration   // backoff before the first retry, doubled for every retry after
+	MaxDelay    time.Duration   // upper bound for the backoff and for Retry-After, 0 for none
+	Classifier  RetryClassifier // DefaultRetryClassifier when nil
+}
+
+// DefaultRetryPolicy retries transient errors up to 3 times, starting at 500ms of backoff
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Classifier:  DefaultRetryClassifier,
+	}
+}
+
+// DefaultRetryClassifier retries connection resets and timeouts, HTTP 429, 502, 503 and 504,
+// and the REQUEST_LIMIT_EXCEEDED, UNABLE_TO_LOCK_ROW and SERVER_UNAVAILABLE error codes.
+// Custom classifiers can call it and add their own conditions.
+func DefaultRetryClassifier(
+	resp *http.Response,
+	sfErrors []SalesforceErrorMessage,
+	err error,
+) bool {
+	if err != nil {
+		return isTransientNetworkError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return slices.ContainsFunc(sfErrors, func(sfError SalesforceErrorMessage) bool {
+		switch sfError.ErrorCode {
+		case requestLimitExceededError, unableToLockRowError, serverUnavailableError:
+			return true
+		}
+		return false
+	})
+}
+
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.New("retry policy max attempts must be at least 1")
+	}
+	if p.MaxAttempts > 1 && p.BaseDelay <= 0 {
+		return errors.New("retry policy base delay must be greater than 0")
+	}
+	if p.MaxDelay < 0 || (p.MaxDelay > 0 && p.MaxDelay < p.BaseDelay) {
+		return errors.New("retry policy max delay must be 0 or not less than the base delay")
+	}
+	return nil
+}
+
+// backoff returns the delay before the given retry, 1 being the first. The exponential
+// backoff is jittered between half and all of its value so clients don't retry in lockstep.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < math.MaxInt64/2; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	delay = p.capDelay(delay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// capDelay limits delay to MaxDelay, when there is one
+func (p RetryPolicy) capDelay(delay time.Duration) time.Duration {
+	if p.MaxDelay > 0 {
+		return min(delay, p.MaxDelay)
+	}
+	return delay
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+type retryableKey struct{}
+
+// RetryableContext marks the requests made with ctx as safe to resend, for DoRequest calls
+// with a POST or PATCH body that is idempotent
+func RetryableContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableKey{}, true)
+}
+
+// canResend reports whether the request can be sent again without side effects
+func (payload requestPayload) canResend(ctx context.Context) bool {
+	switch payload.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	retryable, _ := ctx.Value(retryableKey{}).(bool)
+	return payload.retryable || retryable
+}
+
+// retryDelay decides whether the failed attempt is retried and how long to wait first.
+// The response body is read to classify the error and replaced so it can be read again.
+func (p RetryPolicy) retryDelay(
+	ctx context.Context,
+	attempt int,
+	payload requestPayload,
+	resp *http.Response,
+	err error,
+) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !payload.canResend(ctx) || ctx.Err() != nil {
+		return 0, false
+	}
+
+	var sfErrors []SalesforceErrorMessage
+	if resp != nil {
+		body, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if readErr != nil {
+			return 0, false
+		}
+		sfErrors = parseSalesforceErrors(body)
+	}
+
+	classifier := p.Classifier
+	if classifier == nil {
+		classifier = DefaultRetryClassifier
+	}
+	if !classifier(resp, sfErrors, err) {
+		return 0, false
+	}
+
+	if delay, ok := retryAfter(resp); ok {
+		return p.capDelay(delay), true
+	}
+	return p.backoff(attempt), true
+}
+
+// sleepContext waits for the delay or until the context is done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,258 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type retryTestResponse struct {
+	status     int
+	errorCode  string
+	retryAfter string
+	hangUp     bool // close the connection without a response
+}
+
+func newRetryTestServer(responses []retryTestResponse, attempts *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(attempts.Add(1)) - 1
+		response := retryTestResponse{status: http.StatusOK}
+		if attempt < len(responses) {
+			response = responses[attempt]
+		}
+		if response.hangUp {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				panic(err)
+			}
+			_ = conn.Close()
+			return
+		}
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		w.WriteHeader(response.status)
+		body, _ := json.Marshal([]SalesforceErrorMessage{{ErrorCode: response.errorCode}})
+		if response.status == http.StatusOK {
+			body = []byte(`{}`)
+		}
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+}
+
+func Test_doRequestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	customClassifier := policy
+	customClassifier.Classifier = func(
+		resp *http.Response,
+		sfErrors []SalesforceErrorMessage,
+		err error,
+	) bool {
+		return DefaultRetryClassifier(resp, sfErrors, err) ||
+			(len(sfErrors) > 0 && sfErrors[0].ErrorCode == "CUSTOM_TRANSIENT")
+	}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		payload      requestPayload
+		retryableCtx bool
+		responses    []retryTestResponse
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name:         "get_service_unavailable",
+			policy:       policy,
+			payload:      requestPayload{method: http.MethodGet},
+			responses:    []retryTestResponse{{status: http.StatusServiceUnavailable}},
+			wantAttempts: 2,
+		},
+		{
+			name:    "patch_unable_to_lock_row",
+			policy:  policy,
+			payload: requestPayload{method: http.MethodPatch, body: "{}", retryable: true},
+			responses: []retryTestResponse{
+				{status: http.StatusBadRequest, errorCode: unableToLockRowError},
+				{status: http.StatusServiceUnavailable, errorCode: serverUnavailableError},
+			},
+			wantAttempts: 3,
+		},
+		{
+			name:    "request_limit_exceeded_with_retry_after",
+			policy:  policy,
+			payload: requestPayload{method: http.MethodGet},
+			responses: []retryTestResponse{
+				{status: http.StatusForbidden, errorCode: requestLimitExceededError, retryAfter: "120"},
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:         "connection_reset",
+			policy:       policy,
+			payload:      requestPayload{method: http.MethodDelete},
+			responses:    []retryTestResponse{{hangUp: true}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "post_not_resent",
+			policy:       policy,
+			payload:      requestPayload{method: http.MethodPost, body: "{}"},
+			responses:    []retryTestResponse{{status: http.StatusServiceUnavailable}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "post_marked_retryable",
+			policy:       policy,
+			payload:      requestPayload{method: http.MethodPost, body: "{}"},
+			retryableCtx: true,
+			responses:    []retryTestResponse{{status: http.StatusServiceUnavailable}},
+			wantAttempts: 2,
+		},
+		{
+			name:    "attempts_exhausted",
+			policy:  policy,
+			payload: requestPayload{method: http.MethodGet},
+			responses: []retryTestResponse{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+			},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:    "permanent_error",
+			policy:  policy,
+			payload: requestPayload{method: http.MethodGet},
+			responses: []retryTestResponse{
+				{status: http.StatusBadRequest, errorCode: "MALFORMED_QUERY"},
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:    "custom_classifier",
+			policy:  customClassifier,
+			payload: requestPayload{method: http.MethodGet},
+			responses: []retryTestResponse{
+				{status: http.StatusBadRequest, errorCode: "CUSTOM_TRANSIENT"},
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:         "retries_disabled_by_default",
+			policy:       RetryPolicy{MaxAttempts: 1},
+			payload:      requestPayload{method: http.MethodGet},
+			responses:    []retryTestResponse{{status: http.StatusServiceUnavailable}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := &atomic.Int32{}
+			server := newRetryTestServer(tt.responses, attempts)
+			defer server.Close()
+			sf := buildSalesforceStruct(&authentication{AccessToken: "1234", InstanceUrl: server.URL})
+			sf.config.retryPolicy = tt.policy
+			ctx := t.Context()
+			if tt.retryableCtx {
+				ctx = RetryableContext(ctx)
+			}
+
+			_, err := doRequest(ctx, sf.auth, sf.config, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("doRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("doRequest() attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_doRequestRetryCanceled(t *testing.T) {
+	attempts := &atomic.Int32{}
+	server := newRetryTestServer(
+		[]retryTestResponse{{status: http.StatusServiceUnavailable, retryAfter: "60"}},
+		attempts,
+	)
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{AccessToken: "1234", InstanceUrl: server.URL})
+	sf.config.retryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err := doRequest(ctx, sf.auth, sf.config, requestPayload{method: http.MethodGet})
+	if err != context.DeadlineExceeded {
+		t.Errorf("doRequest() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("doRequest() attempts = %d, want 1", attempts.Load())
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 1, want: 100 * time.Millisecond},
+		{retry: 3, want: 400 * time.Millisecond},
+		{retry: 5, want: time.Second},
+		{retry: 100, want: time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got := policy.backoff(tt.retry)
+			if got < tt.want/2 || got > tt.want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.retry, got, tt.want/2, tt.want)
+			}
+		}
+	}
+}
+
+func TestRetryPolicy_backoff_noMaxDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 100, BaseDelay: 100 * time.Millisecond}
+	if got := policy.backoff(5); got < 800*time.Millisecond || got > 1600*time.Millisecond {
+		t.Errorf("backoff(5) = %v, want between 800ms and 1.6s", got)
+	}
+	if got := policy.backoff(100); got <= 0 {
+		t.Errorf("backoff(100) = %v, want a positive delay without overflow", got)
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "seconds", header: "30", want: 30 * time.Second, wantOk: true},
+		{name: "past_date", header: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, wantOk: true},
+		{name: "missing", header: "", want: 0, wantOk: false},
+		{name: "invalid", header: "soon", want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			got, ok := retryAfter(resp)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
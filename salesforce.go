@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"slices"
@@ -31,6 +32,8 @@ type SalesforceResult struct {
 	Id      string                   `json:"id"`
 	Errors  []SalesforceErrorMessage `json:"errors"`
 	Success bool                     `json:"success"`
+	Index   int                      `json:"-"` // position of the record in the input slice
+	Key     string                   `json:"-"` // ResultKey of the record, if it implements ResultKeyer
 }
 
 type SalesforceResults struct {
@@ -39,7 +42,7 @@ type SalesforceResults struct {
 }
 
 const (
-	apiVersion                    = "v63.0"
+	apiVersion                    = "v63.0" // default, and the newest version negotiation picks
 	jsonType                      = "application/json"
 	csvType                       = "text/csv"
 	batchSizeMax                  = 200
@@ -164,32 +167,43 @@ func validateObjectWithAssignmentRuleId(sObjectName string) error {
 	return nil
 }
 
+// Init authenticates with the given credentials and returns a ready to use client
 func Init(creds Creds, options ...Option) (*Salesforce, error) {
+	return InitWithContext(context.Background(), creds, options...)
+}
+
+// InitWithContext is like Init but the context controls the authentication requests,
+// so a slow login can be canceled
+func InitWithContext(ctx context.Context, creds Creds, options ...Option) (*Salesforce, error) {
 	var auth *authentication
 	var err error
 	var authFlow AuthFlowType
 
-	// Initialize configuration with defaults
-	config := &configuration{}
-	config.setDefaults()
-
-	// Apply functional options
-	for _, option := range options {
-		if err := option(config); err != nil {
-			return nil, fmt.Errorf("configuration error: %w", err)
-		}
+	config, err := newConfiguration(options...)
+	if err != nil {
+		return nil, err
 	}
 
-	config.configureHttpClient()
+	// a previously stored session can stand in for credentials
+	storedToken, err := config.loadToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading stored token: %w", err)
+	}
+	creds = credsWithStoredToken(creds, storedToken, config.authFlow, config.devicePrompt != nil)
 
-	if creds == (Creds{}) {
+	if creds.JWTSigner == nil && creds == (Creds{}) {
 		return nil, errors.New("creds is empty")
 	}
 
 	// Determine authentication flow and authenticate
-	if creds.Domain != "" && creds.ConsumerKey != "" && creds.ConsumerSecret != "" &&
-		creds.Username != "" && creds.Password != "" && creds.SecurityToken != "" {
-		auth, err = usernamePasswordFlow(
+	authFlow, err = validateCredsForFlow(creds, config.authFlow, config.devicePrompt != nil)
+	if err != nil {
+		return nil, err
+	}
+	switch authFlow {
+	case AuthFlowUsernamePassword:
+		auth, err = config.usernamePasswordFlow(
+			ctx,
 			creds.Domain,
 			creds.Username,
 			creds.Password,
@@ -197,31 +211,57 @@ func Init(creds Creds, options ...Option) (*Salesforce, error) {
 			creds.ConsumerKey,
 			creds.ConsumerSecret,
 		)
-		authFlow = AuthFlowUsernamePassword
-	} else if creds.Domain != "" && creds.ConsumerKey != "" && creds.ConsumerSecret != "" {
-		auth, err = clientCredentialsFlow(
+	case AuthFlowRefreshToken:
+		if storedToken != nil && storedToken.AccessToken != "" {
+			// resume the stored session, it is refreshed on the first INVALID_SESSION_ID
+			auth = storedToken.toAuthentication()
+			auth.RefreshToken = creds.RefreshToken
+			auth.grantType = grantTypeRefreshToken
+		} else {
+			auth, err = config.refreshTokenFlow(
+				ctx,
+				creds.Domain,
+				creds.RefreshToken,
+				creds.ConsumerKey,
+				creds.ConsumerSecret,
+			)
+		}
+	case AuthFlowDevice:
+		auth, err = config.deviceFlow(
+			ctx,
+			creds.Domain,
+			creds.ConsumerKey,
+			creds.ConsumerSecret,
+			config.devicePrompt,
+		)
+		if auth != nil {
+			creds.RefreshToken = auth.RefreshToken
+		}
+	case AuthFlowClientCredentials:
+		auth, err = config.clientCredentialsFlow(
+			ctx,
 			creds.Domain,
 			creds.ConsumerKey,
 			creds.ConsumerSecret,
 		)
-		authFlow = AuthFlowClientCredentials
-	} else if creds.AccessToken != "" {
+	case AuthFlowAccessToken:
 		auth, err = config.getAccessTokenAuthentication(
-			context.Background(),
+			ctx,
 			creds.Domain,
 			creds.AccessToken,
 		)
-		authFlow = AuthFlowAccessToken
-	} else if creds.Domain != "" && creds.Username != "" &&
-		creds.ConsumerKey != "" && creds.ConsumerRSAPem != "" {
-		auth, err = jwtFlow(
+	case AuthFlowJWT:
+		signer, signerErr := jwtSignerFromCreds(creds)
+		if signerErr != nil {
+			return nil, signerErr
+		}
+		auth, err = config.jwtFlow(
+			ctx,
 			creds.Domain,
 			creds.Username,
 			creds.ConsumerKey,
-			creds.ConsumerRSAPem,
-			JwtExpirationTime,
+			signer,
 		)
-		authFlow = AuthFlowJWT
 	}
 
 	if err != nil {
@@ -231,11 +271,27 @@ func Init(creds Creds, options ...Option) (*Salesforce, error) {
 	}
 	auth.creds = creds
 
-	return &Salesforce{
+	if err := config.saveToken(ctx, auth); err != nil {
+		return nil, fmt.Errorf("saving token: %w", err)
+	}
+
+	sf := &Salesforce{
 		auth:     auth,
 		config:   config,
 		AuthFlow: authFlow,
-	}, nil
+	}
+	if config.negotiateAPIVersion {
+		versions, err := sf.Versions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing API versions: %w", err)
+		}
+		version, err := negotiateAPIVersion(versions, config.apiVersion)
+		if err != nil {
+			return nil, err
+		}
+		config.apiVersion = version
+	}
+	return sf, nil
 }
 
 func (sf *Salesforce) DoRequest(
@@ -243,18 +299,25 @@ func (sf *Salesforce) DoRequest(
 	method string,
 	uri string,
 	body []byte,
-) (*http.Response, error) {
+) (response *http.Response, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DoRequest",
+		Attribute{Key: AttrHTTPMethod, Value: method},
+		Attribute{Key: AttrURI, Value: uri},
+	)
+	defer endSpan(span, &err)
+
 	authErr := validateAuth(*sf)
 	if authErr != nil {
 		return nil, authErr
 	}
 
 	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
-		method:   method,
-		uri:      uri,
-		content:  jsonType,
-		body:     string(body),
-		compress: sf.config.compressionHeaders,
+		method:    method,
+		uri:       uri,
+		content:   jsonType,
+		body:      string(body),
+		compress:  sf.config.compressionHeaders,
+		operation: OperationDoRequest,
 	})
 	if err != nil {
 		return nil, err
@@ -263,7 +326,10 @@ func (sf *Salesforce) DoRequest(
 	return resp, nil
 }
 
-func (sf *Salesforce) Query(ctx context.Context, query string, sObject any) error {
+func (sf *Salesforce) Query(ctx context.Context, query string, sObject any) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Query")
+	defer endSpan(span, &err)
+
 	authErr := validateAuth(*sf)
 	if authErr != nil {
 		return authErr
@@ -277,7 +343,10 @@ func (sf *Salesforce) Query(ctx context.Context, query string, sObject any) erro
 	return nil
 }
 
-func (sf *Salesforce) QueryStruct(ctx context.Context, soqlStruct any, sObject any) error {
+func (sf *Salesforce) QueryStruct(ctx context.Context, soqlStruct any, sObject any) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "QueryStruct")
+	defer endSpan(span, &err)
+
 	validationErr := validateGoSoql(*sf, soqlStruct)
 	if validationErr != nil {
 		return validationErr
@@ -299,7 +368,10 @@ func (sf *Salesforce) InsertOne(
 	ctx context.Context,
 	sObjectName string,
 	record any,
-) (SalesforceResult, error) {
+) (result SalesforceResult, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertOne", sObjectAttr(sObjectName))
+	defer endSpan(span, &err)
+
 	validationErr := validateSingles(*sf, record)
 	if validationErr != nil {
 		return SalesforceResult{}, validationErr
@@ -308,7 +380,10 @@ func (sf *Salesforce) InsertOne(
 	return sf.doInsertOne(ctx, sObjectName, record)
 }
 
-func (sf *Salesforce) UpdateOne(ctx context.Context, sObjectName string, record any) error {
+func (sf *Salesforce) UpdateOne(ctx context.Context, sObjectName string, record any) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateOne", sObjectAttr(sObjectName))
+	defer endSpan(span, &err)
+
 	validationErr := validateSingles(*sf, record)
 	if validationErr != nil {
 		return validationErr
@@ -322,7 +397,13 @@ func (sf *Salesforce) UpsertOne(
 	sObjectName string,
 	externalIdFieldName string,
 	record any,
-) (SalesforceResult, error) {
+) (result SalesforceResult, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertOne",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateSingles(*sf, record)
 	if validationErr != nil {
 		return SalesforceResult{}, validationErr
@@ -331,7 +412,10 @@ func (sf *Salesforce) UpsertOne(
 	return sf.doUpsertOne(ctx, sObjectName, externalIdFieldName, record)
 }
 
-func (sf *Salesforce) DeleteOne(ctx context.Context, sObjectName string, record any) error {
+func (sf *Salesforce) DeleteOne(ctx context.Context, sObjectName string, record any) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DeleteOne", sObjectAttr(sObjectName))
+	defer endSpan(span, &err)
+
 	validationErr := validateSingles(*sf, record)
 	if validationErr != nil {
 		return validationErr
@@ -345,7 +429,13 @@ func (sf *Salesforce) InsertCollection(
 	sObjectName string,
 	records any,
 	batchSize int,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertCollection",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -359,7 +449,13 @@ func (sf *Salesforce) UpdateCollection(
 	sObjectName string,
 	records any,
 	batchSize int,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateCollection",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -374,7 +470,14 @@ func (sf *Salesforce) UpsertCollection(
 	externalIdFieldName string,
 	records any,
 	batchSize int,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertCollection",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -388,7 +491,13 @@ func (sf *Salesforce) DeleteCollection(
 	sObjectName string,
 	records any,
 	batchSize int,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DeleteCollection",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -403,7 +512,13 @@ func (sf *Salesforce) InsertComposite(
 	records any,
 	batchSize int,
 	allOrNone bool,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertComposite",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -418,7 +533,13 @@ func (sf *Salesforce) UpdateComposite(
 	records any,
 	batchSize int,
 	allOrNone bool,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateComposite",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -434,7 +555,14 @@ func (sf *Salesforce) UpsertComposite(
 	records any,
 	batchSize int,
 	allOrNone bool,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertComposite",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -456,7 +584,13 @@ func (sf *Salesforce) DeleteComposite(
 	records any,
 	batchSize int,
 	allOrNone bool,
-) (SalesforceResults, error) {
+) (results SalesforceResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DeleteComposite",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
 	validationErr := validateCollections(*sf, records, batchSize)
 	if validationErr != nil {
 		return SalesforceResults{}, validationErr
@@ -465,7 +599,14 @@ func (sf *Salesforce) DeleteComposite(
 	return sf.doDeleteComposite(ctx, sObjectName, records, allOrNone, batchSize)
 }
 
-func (sf *Salesforce) QueryBulkExport(ctx context.Context, query string, filePath string) error {
+func (sf *Salesforce) QueryBulkExport(
+	ctx context.Context,
+	query string,
+	filePath string,
+) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "QueryBulkExport")
+	defer endSpan(span, &err)
+
 	authErr := validateAuth(*sf)
 	if authErr != nil {
 		return authErr
@@ -482,7 +623,10 @@ func (sf *Salesforce) QueryStructBulkExport(
 	ctx context.Context,
 	soqlStruct any,
 	filePath string,
-) error {
+) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "QueryStructBulkExport")
+	defer endSpan(span, &err)
+
 	validationErr := validateGoSoql(*sf, soqlStruct)
 	if validationErr != nil {
 		return validationErr
@@ -500,7 +644,13 @@ func (sf *Salesforce) QueryStructBulkExport(
 	return nil
 }
 
-func (sf *Salesforce) QueryBulkIterator(ctx context.Context, query string) (IteratorJob, error) {
+func (sf *Salesforce) QueryBulkIterator(
+	ctx context.Context,
+	query string,
+) (iterator IteratorJob, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "QueryBulkIterator")
+	defer endSpan(span, &err)
+
 	authErr := validateAuth(*sf)
 	if authErr != nil {
 		return nil, authErr
@@ -531,8 +681,23 @@ func (sf *Salesforce) InsertBulk(
 	records any,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.InsertBulkAssign(ctx, sObjectName, records, batchSize, waitForResults, "")
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertBulk",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJob(
+		ctx,
+		sObjectName,
+		"",
+		insertOperation,
+		records,
+		batchSize,
+		waitForResults,
+		"",
+	)
 }
 
 func (sf *Salesforce) InsertBulkAssign(
@@ -542,13 +707,14 @@ func (sf *Salesforce) InsertBulkAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, records, batchSize, false, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertBulkAssign",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJob(
+	return sf.bulkJob(
 		ctx,
 		sObjectName,
 		"",
@@ -558,11 +724,6 @@ func (sf *Salesforce) InsertBulkAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) InsertBulkFile(
@@ -571,8 +732,23 @@ func (sf *Salesforce) InsertBulkFile(
 	filePath string,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.InsertBulkFileAssign(ctx, sObjectName, filePath, batchSize, waitForResults, "")
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertBulkFile",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJobWithFile(
+		ctx,
+		sObjectName,
+		"",
+		insertOperation,
+		filePath,
+		batchSize,
+		waitForResults,
+		"",
+	)
 }
 
 func (sf *Salesforce) InsertBulkFileAssign(
@@ -582,13 +758,14 @@ func (sf *Salesforce) InsertBulkFileAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, nil, batchSize, true, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "InsertBulkFileAssign",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJobWithFile(
+	return sf.bulkJobWithFile(
 		ctx,
 		sObjectName,
 		"",
@@ -598,11 +775,6 @@ func (sf *Salesforce) InsertBulkFileAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) UpdateBulk(
@@ -611,8 +783,23 @@ func (sf *Salesforce) UpdateBulk(
 	records any,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.UpdateBulkAssign(ctx, sObjectName, records, batchSize, waitForResults, "")
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateBulk",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJob(
+		ctx,
+		sObjectName,
+		"",
+		updateOperation,
+		records,
+		batchSize,
+		waitForResults,
+		"",
+	)
 }
 
 func (sf *Salesforce) UpdateBulkAssign(
@@ -622,13 +809,14 @@ func (sf *Salesforce) UpdateBulkAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, records, batchSize, false, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateBulkAssign",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJob(
+	return sf.bulkJob(
 		ctx,
 		sObjectName,
 		"",
@@ -638,11 +826,6 @@ func (sf *Salesforce) UpdateBulkAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) UpdateBulkFile(
@@ -651,8 +834,23 @@ func (sf *Salesforce) UpdateBulkFile(
 	filePath string,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.UpdateBulkFileAssign(ctx, sObjectName, filePath, batchSize, waitForResults, "")
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateBulkFile",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJobWithFile(
+		ctx,
+		sObjectName,
+		"",
+		updateOperation,
+		filePath,
+		batchSize,
+		waitForResults,
+		"",
+	)
 }
 
 func (sf *Salesforce) UpdateBulkFileAssign(
@@ -662,13 +860,14 @@ func (sf *Salesforce) UpdateBulkFileAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, nil, batchSize, true, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpdateBulkFileAssign",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJobWithFile(
+	return sf.bulkJobWithFile(
 		ctx,
 		sObjectName,
 		"",
@@ -678,11 +877,6 @@ func (sf *Salesforce) UpdateBulkFileAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) UpsertBulk(
@@ -692,11 +886,19 @@ func (sf *Salesforce) UpsertBulk(
 	records any,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.UpsertBulkAssign(
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertBulk",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJob(
 		ctx,
 		sObjectName,
 		externalIdFieldName,
+		upsertOperation,
 		records,
 		batchSize,
 		waitForResults,
@@ -712,13 +914,15 @@ func (sf *Salesforce) UpsertBulkAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, records, batchSize, false, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertBulkAssign",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJob(
+	return sf.bulkJob(
 		ctx,
 		sObjectName,
 		externalIdFieldName,
@@ -728,11 +932,6 @@ func (sf *Salesforce) UpsertBulkAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) UpsertBulkFile(
@@ -742,11 +941,19 @@ func (sf *Salesforce) UpsertBulkFile(
 	filePath string,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	return sf.UpsertBulkFileAssign(
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertBulkFile",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
+
+	return sf.bulkJobWithFile(
 		ctx,
 		sObjectName,
 		externalIdFieldName,
+		upsertOperation,
 		filePath,
 		batchSize,
 		waitForResults,
@@ -762,13 +969,15 @@ func (sf *Salesforce) UpsertBulkFileAssign(
 	batchSize int,
 	waitForResults bool,
 	assignmentRuleId string,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, nil, batchSize, true, sObjectName, assignmentRuleId)
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "UpsertBulkFileAssign",
+		sObjectAttr(sObjectName),
+		externalIdFieldAttr(externalIdFieldName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJobWithFile(
+	return sf.bulkJobWithFile(
 		ctx,
 		sObjectName,
 		externalIdFieldName,
@@ -778,11 +987,6 @@ func (sf *Salesforce) UpsertBulkFileAssign(
 		waitForResults,
 		assignmentRuleId,
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) DeleteBulk(
@@ -791,13 +995,14 @@ func (sf *Salesforce) DeleteBulk(
 	records any,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, records, batchSize, false, sObjectName, "")
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DeleteBulk",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJob(
+	return sf.bulkJob(
 		ctx,
 		sObjectName,
 		"",
@@ -807,11 +1012,6 @@ func (sf *Salesforce) DeleteBulk(
 		waitForResults,
 		"",
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
 func (sf *Salesforce) DeleteBulkFile(
@@ -820,13 +1020,14 @@ func (sf *Salesforce) DeleteBulkFile(
 	filePath string,
 	batchSize int,
 	waitForResults bool,
-) ([]string, error) {
-	validationErr := validateBulk(*sf, nil, batchSize, true, sObjectName, "")
-	if validationErr != nil {
-		return []string{}, validationErr
-	}
+) (jobIds []string, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "DeleteBulkFile",
+		sObjectAttr(sObjectName),
+		batchSizeAttr(batchSize),
+	)
+	defer endSpan(span, &err)
 
-	jobIds, bulkErr := sf.doBulkJobWithFile(
+	return sf.bulkJobWithFile(
 		ctx,
 		sObjectName,
 		"",
@@ -836,14 +1037,15 @@ func (sf *Salesforce) DeleteBulkFile(
 		waitForResults,
 		"",
 	)
-	if bulkErr != nil {
-		return []string{}, bulkErr
-	}
-
-	return jobIds, nil
 }
 
-func (sf *Salesforce) GetJobResults(ctx context.Context, bulkJobId string) (BulkJobResults, error) {
+func (sf *Salesforce) GetJobResults(
+	ctx context.Context,
+	bulkJobId string,
+) (results BulkJobResults, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "GetJobResults", jobIdAttr(bulkJobId))
+	defer endSpan(span, &err)
+
 	authErr := validateAuth(*sf)
 	if authErr != nil {
 		return BulkJobResults{}, authErr
@@ -894,11 +1096,70 @@ func (sf *Salesforce) GetHTTPClient() *http.Client {
 	return sf.config.httpClient
 }
 
+// Refresh forces a new session to be obtained with the credentials used during Init
+func (sf *Salesforce) Refresh(ctx context.Context) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Refresh")
+	defer endSpan(span, &err)
+
+	authErr := validateAuth(*sf)
+	if authErr != nil {
+		return authErr
+	}
+	if !canRefreshSession(sf.auth.grantType) {
+		return errors.New("session can't be refreshed for auth flow: " + sf.AuthFlow.String())
+	}
+
+	return sf.config.refreshSessionOnce(ctx, sf.auth, sf.auth.accessToken())
+}
+
+// Logout revokes the refresh token, or the access token when there is none, and clears the
+// session and the token store. Every call made afterwards returns ErrLoggedOut.
+func (sf *Salesforce) Logout(ctx context.Context) (err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Logout")
+	defer endSpan(span, &err)
+
+	authErr := validateAuth(*sf)
+	if authErr != nil {
+		return authErr
+	}
+
+	sf.auth.mu.RLock()
+	token := sf.auth.RefreshToken
+	if token == "" {
+		token = sf.auth.AccessToken
+	}
+	sf.auth.mu.RUnlock()
+	if err := sf.config.revokeToken(ctx, sf.auth.InstanceUrl, token); err != nil {
+		return fmt.Errorf("revoking token: %w", err)
+	}
+
+	sf.auth.mu.Lock()
+	sf.auth.loggedOut = true
+	sf.auth.AccessToken = ""
+	sf.auth.RefreshToken = ""
+	sf.auth.Signature = ""
+	sf.auth.expiresAt = time.Time{}
+	sf.auth.mu.Unlock()
+	sf.config.log().LogAttrs(ctx, slog.LevelInfo, "salesforce session logged out",
+		slog.String("instance_url", sf.auth.InstanceUrl),
+	)
+
+	if sf.config.tokenStore != nil {
+		// a refresh that saves after this sees the session logged out and skips the save
+		sf.auth.storeMu.Lock()
+		defer sf.auth.storeMu.Unlock()
+		if err := sf.config.tokenStore.Delete(ctx); err != nil {
+			return fmt.Errorf("deleting stored token: %w", err)
+		}
+	}
+	return nil
+}
+
 func (sf *Salesforce) GetAccessToken() string {
 	if sf.auth == nil {
 		return ""
 	}
-	return sf.auth.AccessToken
+	return sf.auth.accessToken()
 }
 
 func (sf *Salesforce) GetInstanceUrl() string {
@@ -3,7 +3,9 @@ package salesforce
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -11,11 +13,12 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 )
 
-func setupTestServer(body any, status int) (*httptest.Server, authentication) {
+func setupTestServer(body any, status int) (*httptest.Server, *authentication) {
 	respBody, _ := json.Marshal(body)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Content-Encoding") == "gzip" {
@@ -42,7 +45,7 @@ func setupTestServer(body any, status int) (*httptest.Server, authentication) {
 		}
 	}))
 
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -221,7 +224,7 @@ func Test_validateBatchSizeWithinRange(t *testing.T) {
 }
 
 func TestInit(t *testing.T) {
-	sfAuthUsernamePassword := authentication{
+	sfAuthUsernamePassword := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -241,7 +244,7 @@ func TestInit(t *testing.T) {
 	}
 	sfAuthUsernamePassword.creds = credsUsernamePassword
 
-	sfAuthClientCredentials := authentication{
+	sfAuthClientCredentials := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -258,7 +261,7 @@ func TestInit(t *testing.T) {
 	}
 	sfAuthClientCredentials.creds = credsClientCredentials
 
-	sfAuthAccessToken := authentication{
+	sfAuthAccessToken := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -274,7 +277,7 @@ func TestInit(t *testing.T) {
 	}
 	sfAuthAccessToken.creds = credsAccessToken
 
-	sfAuthJwt := authentication{
+	sfAuthJwt := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -311,13 +314,13 @@ func TestInit(t *testing.T) {
 		{
 			name:    "authentication_username_password",
 			args:    args{creds: sfAuthUsernamePassword.creds},
-			want:    buildSalesforceStruct(&sfAuthUsernamePassword),
+			want:    buildSalesforceStruct(sfAuthUsernamePassword),
 			wantErr: false,
 		},
 		{
 			name:    "authentication_client_credentials",
 			args:    args{creds: credsClientCredentials},
-			want:    buildSalesforceStruct(&sfAuthClientCredentials),
+			want:    buildSalesforceStruct(sfAuthClientCredentials),
 			wantErr: false,
 		},
 		{
@@ -340,14 +343,189 @@ func TestInit(t *testing.T) {
 			}
 			if tt.want != nil && !tt.wantErr {
 				// Compare only the authentication parts since the config and AuthFlow are now different
-				if !reflect.DeepEqual(*got.auth, *tt.want.auth) {
-					t.Errorf("Init() = %v, want %v", *got.auth, *tt.want.auth)
+				if !reflect.DeepEqual(got.auth, tt.want.auth) {
+					t.Errorf("Init() = %v, want %v", got.auth, tt.want.auth)
 				}
 			}
 		})
 	}
 }
 
+func TestInit_refreshTokenWithTokenStore(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		body, _ := json.Marshal(authentication{
+			AccessToken:  "1234",
+			RefreshToken: "refresh",
+			InstanceUrl:  "example.com",
+		})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryTokenStore()
+	creds := Creds{
+		Domain:       server.URL,
+		ConsumerKey:  "key",
+		RefreshToken: "refresh",
+	}
+	sf, err := Init(creds, WithTokenStore(store))
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if sf.GetAuthFlow() != AuthFlowRefreshToken {
+		t.Errorf("Init() AuthFlow = %v, want %v", sf.GetAuthFlow(), AuthFlowRefreshToken)
+	}
+	stored, _ := store.Load(t.Context())
+	if stored == nil || stored.AccessToken != "1234" || stored.RefreshToken != "refresh" {
+		t.Fatalf("Init() stored token = %v", stored)
+	}
+
+	// a restarted client resumes the stored session without logging in again
+	resumed, err := Init(Creds{ConsumerKey: "key"}, WithTokenStore(store))
+	if err != nil {
+		t.Fatalf("Init() from stored token error = %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("Init() from stored token made %d token requests, want 1", tokenRequests)
+	}
+	if resumed.GetAccessToken() != "1234" || resumed.auth.grantType != grantTypeRefreshToken {
+		t.Errorf("Init() from stored token auth = %v", resumed.auth)
+	}
+
+	if _, err := Init(Creds{}, WithTokenStore(NewMemoryTokenStore())); err == nil {
+		t.Error("Init() with empty creds and empty store should return an error")
+	}
+}
+
+func TestInit_withAuthFlow(t *testing.T) {
+	grantTypes := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		grantTypes = append(grantTypes, r.PostForm.Get("grant_type"))
+		body, _ := json.Marshal(authentication{AccessToken: "1234"})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	sampleKey, _ := os.ReadFile("test/sample_key.pem")
+	creds := Creds{
+		Domain:         server.URL,
+		Username:       "user",
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+		ConsumerRSAPem: string(sampleKey),
+	}
+	sf, err := Init(creds, WithAuthFlow(AuthFlowJWT), WithValidateAuthentication(false))
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if sf.GetAuthFlow() != AuthFlowJWT || !reflect.DeepEqual(grantTypes, []string{grantTypeJWT}) {
+		t.Errorf("Init() flow = %v, grant types = %v", sf.GetAuthFlow(), grantTypes)
+	}
+
+	creds.ConsumerRSAPem = ""
+	creds.Password = "pass"
+	_, err = Init(creds, WithAuthFlow(AuthFlowJWT))
+	credsErr := &CredsError{}
+	if !errors.As(err, &credsErr) {
+		t.Fatalf("Init() error = %v, want a *CredsError", err)
+	}
+	want := "invalid creds for JWT flow: missing ConsumerRSAPem or JWTSigner: conflicting Password"
+	if credsErr.Error() != want {
+		t.Errorf("Init() error = %q, want %q", credsErr.Error(), want)
+	}
+	if len(grantTypes) != 1 {
+		t.Errorf("Init() with invalid creds made a token request")
+	}
+}
+
+func TestInit_deviceFlow(t *testing.T) {
+	pollInterval := deviceDefaultPollInterval
+	deviceDefaultPollInterval = time.Millisecond
+	t.Cleanup(func() { deviceDefaultPollInterval = pollInterval })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		var body []byte
+		if r.PostForm.Get("response_type") == "device_code" {
+			body, _ = json.Marshal(deviceCodeResponse{DeviceCode: "devicecode"})
+		} else {
+			body, _ = json.Marshal(authentication{AccessToken: "1234", RefreshToken: "refresh"})
+		}
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	prompted := false
+	store := NewMemoryTokenStore()
+	sf, err := Init(
+		Creds{Domain: server.URL, ConsumerKey: "key"},
+		WithDeviceFlow(func(DeviceAuthorization) { prompted = true }),
+		WithTokenStore(store),
+	)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if !prompted {
+		t.Error("Init() did not report the device authorization")
+	}
+	if sf.GetAuthFlow() != AuthFlowDevice || sf.auth.creds.RefreshToken != "refresh" {
+		t.Errorf("Init() flow = %v, creds = %v", sf.GetAuthFlow(), sf.auth.creds)
+	}
+	if stored, _ := store.Load(t.Context()); stored == nil || stored.RefreshToken != "refresh" {
+		t.Errorf("Init() stored token = %v", stored)
+	}
+}
+
+func TestInit_forcedFlowWithTokenStore(t *testing.T) {
+	pollInterval := deviceDefaultPollInterval
+	deviceDefaultPollInterval = time.Millisecond
+	t.Cleanup(func() { deviceDefaultPollInterval = pollInterval })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			panic(err)
+		}
+		var body []byte
+		if r.PostForm.Get("response_type") == "device_code" {
+			body, _ = json.Marshal(deviceCodeResponse{DeviceCode: "devicecode"})
+		} else {
+			body, _ = json.Marshal(authentication{AccessToken: "1234", RefreshToken: "refresh"})
+		}
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryTokenStore()
+	for i := range 2 {
+		// the second Init finds the refresh token saved by the first one in the store
+		sf, err := Init(
+			Creds{Domain: server.URL, ConsumerKey: "key"},
+			WithAuthFlow(AuthFlowDevice),
+			WithDeviceFlow(func(DeviceAuthorization) {}),
+			WithTokenStore(store),
+		)
+		if err != nil {
+			t.Fatalf("Init() #%d error = %v", i+1, err)
+		}
+		if sf.GetAuthFlow() != AuthFlowDevice {
+			t.Errorf("Init() #%d flow = %v, want %v", i+1, sf.GetAuthFlow(), AuthFlowDevice)
+		}
+	}
+}
+
 func Test_validateSingles(t *testing.T) {
 	type account struct{}
 
@@ -622,7 +800,7 @@ func TestSalesforce_DoRequest(t *testing.T) {
 		{
 			name: "successful_request",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				method: http.MethodGet,
@@ -720,7 +898,7 @@ func TestSalesforce_Query(t *testing.T) {
 		{
 			name: "successful_query",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				query:   "SELECT Id FROM Account",
@@ -791,7 +969,7 @@ func TestSalesforce_QueryStruct(t *testing.T) {
 		{
 			name: "successful_query",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				soqlStruct: account{},
@@ -848,7 +1026,7 @@ func TestSalesforce_InsertOne(t *testing.T) {
 		{
 			name: "successful_insert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -862,7 +1040,7 @@ func TestSalesforce_InsertOne(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -910,7 +1088,7 @@ func TestSalesforce_UpdateOne(t *testing.T) {
 		{
 			name: "successful_update",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -924,7 +1102,7 @@ func TestSalesforce_UpdateOne(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -935,7 +1113,7 @@ func TestSalesforce_UpdateOne(t *testing.T) {
 		{
 			name: "fail_no_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -989,7 +1167,7 @@ func TestSalesforce_UpsertOne(t *testing.T) {
 		{
 			name: "successful_upsert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1005,7 +1183,7 @@ func TestSalesforce_UpsertOne(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1018,7 +1196,7 @@ func TestSalesforce_UpsertOne(t *testing.T) {
 		{
 			name: "fail_no_external_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1073,7 +1251,7 @@ func TestSalesforce_DeleteOne(t *testing.T) {
 		{
 			name: "successful_delete",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1086,7 +1264,7 @@ func TestSalesforce_DeleteOne(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1097,7 +1275,7 @@ func TestSalesforce_DeleteOne(t *testing.T) {
 		{
 			name: "fail_no_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1154,7 +1332,7 @@ func TestSalesforce_InsertCollection(t *testing.T) {
 		{
 			name: "successful_insert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1174,7 +1352,7 @@ func TestSalesforce_InsertCollection(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1189,7 +1367,7 @@ func TestSalesforce_InsertCollection(t *testing.T) {
 		{
 			name: "bad_request",
 			fields: fields{
-				auth: &badReqSfAuth,
+				auth: badReqSfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1259,7 +1437,7 @@ func TestSalesforce_UpdateCollection(t *testing.T) {
 		{
 			name: "successful_update",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1281,7 +1459,7 @@ func TestSalesforce_UpdateCollection(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1294,7 +1472,7 @@ func TestSalesforce_UpdateCollection(t *testing.T) {
 		{
 			name: "fail_no_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1368,7 +1546,7 @@ func TestSalesforce_UpsertCollection(t *testing.T) {
 		{
 			name: "successful_upsert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1391,7 +1569,7 @@ func TestSalesforce_UpsertCollection(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1405,7 +1583,7 @@ func TestSalesforce_UpsertCollection(t *testing.T) {
 		{
 			name: "fail_no_external_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1479,7 +1657,7 @@ func TestSalesforce_DeleteCollection(t *testing.T) {
 		{
 			name: "successful_delete",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1499,7 +1677,7 @@ func TestSalesforce_DeleteCollection(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1512,7 +1690,7 @@ func TestSalesforce_DeleteCollection(t *testing.T) {
 		{
 			name: "fail_no_id",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1582,7 +1760,7 @@ func TestSalesforce_InsertComposite(t *testing.T) {
 		{
 			name: "successful_insert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1606,7 +1784,7 @@ func TestSalesforce_InsertComposite(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1622,7 +1800,7 @@ func TestSalesforce_InsertComposite(t *testing.T) {
 		{
 			name: "bad_request",
 			fields: fields{
-				auth: &badReqSfAuth,
+				auth: badReqSfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1699,7 +1877,7 @@ func TestSalesforce_UpdateComposite(t *testing.T) {
 		{
 			name: "successful_update",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1725,7 +1903,7 @@ func TestSalesforce_UpdateComposite(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1739,7 +1917,7 @@ func TestSalesforce_UpdateComposite(t *testing.T) {
 		{
 			name: "bad_request",
 			fields: fields{
-				auth: &badReqSfAuth,
+				auth: badReqSfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1818,7 +1996,7 @@ func TestSalesforce_UpsertComposite(t *testing.T) {
 		{
 			name: "successful_upsert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1845,7 +2023,7 @@ func TestSalesforce_UpsertComposite(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1860,7 +2038,7 @@ func TestSalesforce_UpsertComposite(t *testing.T) {
 		{
 			name: "bad_request",
 			fields: fields{
-				auth: &badReqSfAuth,
+				auth: badReqSfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -1939,7 +2117,7 @@ func TestSalesforce_DeleteComposite(t *testing.T) {
 		{
 			name: "successful_delete",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1962,7 +2140,7 @@ func TestSalesforce_DeleteComposite(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -1975,7 +2153,7 @@ func TestSalesforce_DeleteComposite(t *testing.T) {
 		{
 			name: "bad_req",
 			fields: fields{
-				auth: &badReqSfAuth,
+				auth: badReqSfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2038,7 +2216,7 @@ func TestSalesforce_InsertBulk(t *testing.T) {
 		{
 			name: "successful_insert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2058,7 +2236,7 @@ func TestSalesforce_InsertBulk(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2123,7 +2301,7 @@ func TestSalesforce_InsertBulkAssign(t *testing.T) {
 		{
 			name: "successful_insert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Lead",
@@ -2144,7 +2322,7 @@ func TestSalesforce_InsertBulkAssign(t *testing.T) {
 		{
 			name: "object_validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2216,7 +2394,7 @@ func TestSalesforce_UpdateBulk(t *testing.T) {
 		{
 			name: "successful_update",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2238,7 +2416,7 @@ func TestSalesforce_UpdateBulk(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2302,7 +2480,7 @@ func TestSalesforce_UpdateBulkAssign(t *testing.T) {
 		{
 			name: "successful_update",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Lead",
@@ -2325,7 +2503,7 @@ func TestSalesforce_UpdateBulkAssign(t *testing.T) {
 		{
 			name: "object_validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2400,7 +2578,7 @@ func TestSalesforce_UpsertBulk(t *testing.T) {
 		{
 			name: "successful_upsert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -2423,7 +2601,7 @@ func TestSalesforce_UpsertBulk(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -2490,7 +2668,7 @@ func TestSalesforce_UpsertBulkAssign(t *testing.T) {
 		{
 			name: "successful_upsert",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Lead",
@@ -2514,7 +2692,7 @@ func TestSalesforce_UpsertBulkAssign(t *testing.T) {
 		{
 			name: "object_validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -2589,7 +2767,7 @@ func TestSalesforce_DeleteBulk(t *testing.T) {
 		{
 			name: "successful_delete",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2609,7 +2787,7 @@ func TestSalesforce_DeleteBulk(t *testing.T) {
 		{
 			name: "validation_fail",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName: "Account",
@@ -2667,7 +2845,7 @@ func TestSalesforce_GetJobResults(t *testing.T) {
 		{
 			name: "get_job_results",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				bulkJobId: "1234",
@@ -2736,7 +2914,7 @@ func TestSalesforce_InsertBulkFile(t *testing.T) {
 		{
 			name: "insert bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -2750,7 +2928,7 @@ func TestSalesforce_InsertBulkFile(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -2819,7 +2997,7 @@ func TestSalesforce_InsertBulkFileAssign(t *testing.T) {
 		{
 			name: "insert bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:      "Lead",
@@ -2834,7 +3012,7 @@ func TestSalesforce_InsertBulkFileAssign(t *testing.T) {
 		{
 			name: "object validation errors",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:      "Account",
@@ -2908,7 +3086,7 @@ func TestSalesforce_UpdateBulkFile(t *testing.T) {
 		{
 			name: "update bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -2922,7 +3100,7 @@ func TestSalesforce_UpdateBulkFile(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -2991,7 +3169,7 @@ func TestSalesforce_UpdateBulkFileAssign(t *testing.T) {
 		{
 			name: "update bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:      "Lead",
@@ -3006,7 +3184,7 @@ func TestSalesforce_UpdateBulkFileAssign(t *testing.T) {
 		{
 			name: "object validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:      "Account",
@@ -3081,7 +3259,7 @@ func TestSalesforce_UpsertBulkFile(t *testing.T) {
 		{
 			name: "upsert bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -3096,7 +3274,7 @@ func TestSalesforce_UpsertBulkFile(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -3168,7 +3346,7 @@ func TestSalesforce_UpsertBulkFileAssign(t *testing.T) {
 		{
 			name: "upsert bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Lead",
@@ -3184,7 +3362,7 @@ func TestSalesforce_UpsertBulkFileAssign(t *testing.T) {
 		{
 			name: "object validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:         "Account",
@@ -3260,7 +3438,7 @@ func TestSalesforce_DeleteBulkFile(t *testing.T) {
 		{
 			name: "delete bulk data successfully",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -3274,7 +3452,7 @@ func TestSalesforce_DeleteBulkFile(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				auth: &sfAuth,
+				auth: sfAuth,
 			},
 			args: args{
 				sObjectName:    "Account",
@@ -3339,7 +3517,7 @@ func TestSalesforce_QueryBulkExport(t *testing.T) {
 			}
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -3364,7 +3542,7 @@ func TestSalesforce_QueryBulkExport(t *testing.T) {
 		{
 			name: "export data successfully",
 			fields: fields{
-				&sfAuth,
+				sfAuth,
 			},
 			args: args{
 				query:    "SELECT Id FROM Account",
@@ -3375,7 +3553,7 @@ func TestSalesforce_QueryBulkExport(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				&badAuth,
+				badAuth,
 			},
 			args: args{
 				query:    "SELECT Id FROM Account",
@@ -3430,7 +3608,7 @@ func TestSalesforce_QueryStructBulkExport(t *testing.T) {
 			}
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -3455,7 +3633,7 @@ func TestSalesforce_QueryStructBulkExport(t *testing.T) {
 		{
 			name: "export data successfully",
 			fields: fields{
-				&sfAuth,
+				sfAuth,
 			},
 			args: args{
 				soqlStruct: account{},
@@ -3466,7 +3644,7 @@ func TestSalesforce_QueryStructBulkExport(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				&badAuth,
+				badAuth,
 			},
 			args: args{
 				soqlStruct: account{},
@@ -3521,7 +3699,7 @@ func TestSalesforce_CreateQueryBulkJob(t *testing.T) {
 			}
 		}
 	}))
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		InstanceUrl: server.URL,
 		AccessToken: "accesstokenvalue",
 	}
@@ -3551,7 +3729,7 @@ func TestSalesforce_CreateQueryBulkJob(t *testing.T) {
 		{
 			name: "export data successfully",
 			fields: fields{
-				&sfAuth,
+				sfAuth,
 			},
 			args: args{
 				query: "SELECT Id FROM Account",
@@ -3562,7 +3740,7 @@ func TestSalesforce_CreateQueryBulkJob(t *testing.T) {
 		{
 			name: "validation error",
 			fields: fields{
-				&badAuth,
+				badAuth,
 			},
 			args: args{
 				query: "SELECT Id FROM Account",
@@ -3604,7 +3782,7 @@ func TestSalesforce_CreateQueryBulkJob(t *testing.T) {
 }
 
 func TestGetAccessToken(t *testing.T) {
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -3612,7 +3790,7 @@ func TestGetAccessToken(t *testing.T) {
 		Signature:   "signed",
 	}
 
-	sf := buildSalesforceStruct(&sfAuth)
+	sf := buildSalesforceStruct(sfAuth)
 
 	tests := []struct {
 		name string
@@ -3641,7 +3819,7 @@ func TestGetAccessToken(t *testing.T) {
 }
 
 func TestGetInstanceUrl(t *testing.T) {
-	sfAuth := authentication{
+	sfAuth := &authentication{
 		AccessToken: "1234",
 		InstanceUrl: "example.com",
 		Id:          "123abc",
@@ -3649,7 +3827,7 @@ func TestGetInstanceUrl(t *testing.T) {
 		Signature:   "signed",
 	}
 
-	sf := buildSalesforceStruct(&sfAuth)
+	sf := buildSalesforceStruct(sfAuth)
 
 	tests := []struct {
 		name string
@@ -3676,3 +3854,218 @@ func TestGetInstanceUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestSalesforce_Refresh(t *testing.T) {
+	server, sfAuth := setupTestServer(authentication{AccessToken: "refreshed"}, http.StatusOK)
+	defer server.Close()
+	sfAuth.grantType = grantTypeClientCredentials
+
+	accessTokenServer, sfAuthAccessToken := setupTestServer("", http.StatusOK)
+	defer accessTokenServer.Close()
+	sfAuthAccessToken.grantType = grantTypeAccessToken
+
+	tests := []struct {
+		name    string
+		sf      *Salesforce
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "refresh_client_credentials",
+			sf:      buildSalesforceStruct(sfAuth),
+			want:    "refreshed",
+			wantErr: false,
+		},
+		{
+			name:    "access_token_cannot_refresh",
+			sf:      buildSalesforceStruct(sfAuthAccessToken),
+			want:    "accesstokenvalue",
+			wantErr: true,
+		},
+		{
+			name:    "not_authenticated",
+			sf:      buildSalesforceStruct(&authentication{}),
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sf.Refresh(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Refresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.sf.GetAccessToken(); got != tt.want {
+				t.Errorf("Refresh() access token = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSalesforce_Logout(t *testing.T) {
+	newServer := func(status int, body string, revoked *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/services/oauth2/revoke" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				panic(err)
+			}
+			*revoked = append(*revoked, r.PostForm.Get("token"))
+			w.WriteHeader(status)
+			if _, err := w.Write([]byte(body)); err != nil {
+				panic(err)
+			}
+		}))
+	}
+
+	tests := []struct {
+		name         string
+		auth         *authentication
+		status       int
+		body         string
+		wantRevoked  []string
+		wantErr      bool
+		wantLoggedIn bool
+	}{
+		{
+			name:        "revoke_refresh_token",
+			auth:        &authentication{AccessToken: "1234", RefreshToken: "refresh"},
+			status:      http.StatusOK,
+			wantRevoked: []string{"refresh"},
+		},
+		{
+			name:        "revoke_access_token",
+			auth:        &authentication{AccessToken: "1234"},
+			status:      http.StatusOK,
+			wantRevoked: []string{"1234"},
+		},
+		{
+			name:        "already_revoked",
+			auth:        &authentication{AccessToken: "1234"},
+			status:      http.StatusBadRequest,
+			body:        `{"error":"invalid_token","error_description":"invalid token"}`,
+			wantRevoked: []string{"1234"},
+		},
+		{
+			name:         "revoke_failed",
+			auth:         &authentication{AccessToken: "1234"},
+			status:       http.StatusServiceUnavailable,
+			wantRevoked:  []string{"1234"},
+			wantErr:      true,
+			wantLoggedIn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked := []string{}
+			server := newServer(tt.status, tt.body, &revoked)
+			defer server.Close()
+			auth := tt.auth
+			auth.InstanceUrl = server.URL
+			auth.grantType = grantTypeClientCredentials
+			sf := buildSalesforceStruct(auth)
+			store := NewMemoryTokenStore()
+			sf.config.tokenStore = store
+			if err := store.Save(t.Context(), Token{AccessToken: auth.AccessToken}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := sf.Logout(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Logout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(revoked, tt.wantRevoked) {
+				t.Errorf("Logout() revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+			stored, _ := store.Load(t.Context())
+			if tt.wantLoggedIn {
+				if sf.GetAccessToken() != "1234" || stored == nil {
+					t.Errorf("Logout() cleared the session after a failed revoke")
+				}
+				return
+			}
+			if sf.GetAccessToken() != "" || stored != nil {
+				t.Errorf("Logout() token = %q, stored = %v", sf.GetAccessToken(), stored)
+			}
+			err = sf.Query(t.Context(), "SELECT Id FROM Account", &[]any{})
+			if !errors.Is(err, ErrLoggedOut) {
+				t.Errorf("Query() after Logout() error = %v, want %v", err, ErrLoggedOut)
+			}
+			if err := sf.Refresh(t.Context()); !errors.Is(err, ErrLoggedOut) {
+				t.Errorf("Refresh() after Logout() error = %v, want %v", err, ErrLoggedOut)
+			}
+			if _, err := doRequest(t.Context(), sf.auth, sf.config, requestPayload{
+				method: http.MethodGet,
+				uri:    "/limits",
+			}); !errors.Is(err, ErrLoggedOut) {
+				t.Errorf("doRequest() after Logout() error = %v, want %v", err, ErrLoggedOut)
+			}
+			err = sf.config.refreshSessionOnce(t.Context(), sf.auth, "")
+			if !errors.Is(err, ErrLoggedOut) {
+				t.Errorf("refreshSessionOnce() after Logout() error = %v", err)
+			}
+		})
+	}
+}
+
+// blockingTokenStore holds Save until release is closed
+type blockingTokenStore struct {
+	*MemoryTokenStore
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingTokenStore) Save(ctx context.Context, token Token) error {
+	close(s.saving)
+	<-s.release
+	return s.MemoryTokenStore.Save(ctx, token)
+}
+
+func TestSalesforce_Logout_duringRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/services/oauth2/revoke" {
+			return
+		}
+		body, _ := json.Marshal(authentication{AccessToken: "refreshed"})
+		if _, err := w.Write(body); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "1234",
+		grantType:   grantTypeClientCredentials,
+	})
+	store := &blockingTokenStore{
+		MemoryTokenStore: NewMemoryTokenStore(),
+		saving:           make(chan struct{}),
+		release:          make(chan struct{}),
+	}
+	sf.config.tokenStore = store
+
+	refreshErr := make(chan error)
+	go func() {
+		refreshErr <- sf.config.refreshSessionOnce(t.Context(), sf.auth, "")
+	}()
+	<-store.saving // the refresh has the new token and is saving it
+
+	logoutErr := make(chan error)
+	go func() {
+		logoutErr <- sf.Logout(t.Context())
+	}()
+	time.Sleep(20 * time.Millisecond) // let Logout reach the token store
+	close(store.release)
+
+	if err := <-refreshErr; err != nil {
+		t.Errorf("refreshSessionOnce() error = %v", err)
+	}
+	if err := <-logoutErr; err != nil {
+		t.Errorf("Logout() error = %v", err)
+	}
+	if stored, _ := store.Load(t.Context()); stored != nil {
+		t.Errorf("Logout() left the refreshed token in the store: %v", stored)
+	}
+}
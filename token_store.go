@@ -0,0 +1,194 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// Token is the persisted form of an authenticated session
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	InstanceUrl  string `json:"instance_url"`
+	Id           string `json:"id,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IssuedAt     string `json:"issued_at,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+}
+
+// TokenStore persists session tokens so that a client can resume a session across restarts.
+// Load returns a nil Token and a nil error when nothing has been stored yet.
+type TokenStore interface {
+	Load(ctx context.Context) (*Token, error)
+	Save(ctx context.Context, token Token) error
+	Delete(ctx context.Context) error
+}
+
+func newTokenFromAuthentication(auth *authentication) Token {
+	auth.mu.RLock()
+	defer auth.mu.RUnlock()
+	return Token{
+		AccessToken:  auth.AccessToken,
+		RefreshToken: auth.RefreshToken,
+		InstanceUrl:  auth.InstanceUrl,
+		Id:           auth.Id,
+		TokenType:    auth.TokenType,
+		Scope:        auth.Scope,
+		IssuedAt:     auth.IssuedAt,
+		Signature:    auth.Signature,
+	}
+}
+
+func (t Token) toAuthentication() *authentication {
+	return &authentication{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		InstanceUrl:  t.InstanceUrl,
+		Id:           t.Id,
+		TokenType:    t.TokenType,
+		Scope:        t.Scope,
+		IssuedAt:     t.IssuedAt,
+		Signature:    t.Signature,
+	}
+}
+
+// credsWithStoredToken fills in the creds missing from the stored token. The stored refresh
+// token is only added when the session is resumed with it, any other flow would see it as a
+// conflicting credential.
+func credsWithStoredToken(
+	creds Creds,
+	storedToken *Token,
+	authFlow AuthFlowType,
+	deviceFlowEnabled bool,
+) Creds {
+	if storedToken == nil {
+		return creds
+	}
+	if creds.Domain == "" {
+		creds.Domain = storedToken.InstanceUrl
+	}
+	if creds.RefreshToken == "" && storedToken.RefreshToken != "" {
+		resumed := creds
+		resumed.RefreshToken = storedToken.RefreshToken
+		flow, err := validateCredsForFlow(resumed, authFlow, deviceFlowEnabled)
+		if err == nil && flow == AuthFlowRefreshToken {
+			return resumed
+		}
+	}
+	return creds
+}
+
+func (conf *configuration) loadToken(ctx context.Context) (*Token, error) {
+	if conf.tokenStore == nil {
+		return nil, nil
+	}
+	return conf.tokenStore.Load(ctx)
+}
+
+func (conf *configuration) saveToken(ctx context.Context, auth *authentication) error {
+	if conf.tokenStore == nil || auth == nil {
+		return nil
+	}
+	return conf.tokenStore.Save(ctx, newTokenFromAuthentication(auth))
+}
+
+// MemoryTokenStore is a TokenStore that keeps the token in memory, safe for concurrent use
+type MemoryTokenStore struct {
+	mu    sync.RWMutex
+	token *Token
+}
+
+// NewMemoryTokenStore returns an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (m *MemoryTokenStore) Load(_ context.Context) (*Token, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.token == nil {
+		return nil, nil
+	}
+	token := *m.token
+	return &token, nil
+}
+
+func (m *MemoryTokenStore) Save(_ context.Context, token Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.token = &token
+	return nil
+}
+
+func (m *MemoryTokenStore) Delete(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.token = nil
+	return nil
+}
+
+// FileTokenStore is a TokenStore that keeps the token as JSON in a file readable only by its owner
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore returns a token store backed by the file at path
+func NewFileTokenStore(path string) (*FileTokenStore, error) {
+	if path == "" {
+		return nil, errors.New("token store path cannot be empty")
+	}
+	return &FileTokenStore{path: path}, nil
+}
+
+func (f *FileTokenStore) Load(_ context.Context) (*Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := afero.ReadFile(appFs, f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := &Token{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (f *FileTokenStore) Save(_ context.Context, token Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := appFs.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	// write to a temporary file first so a crash never leaves a truncated token behind
+	tmpPath := f.path + ".tmp"
+	if err := afero.WriteFile(appFs, tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return appFs.Rename(tmpPath, f.path)
+}
+
+func (f *FileTokenStore) Delete(_ context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := appFs.Remove(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package salesforce
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestMemoryTokenStore(t *testing.T) {
+	store := NewMemoryTokenStore()
+	token := Token{AccessToken: "1234", RefreshToken: "refresh", InstanceUrl: "example.com"}
+
+	got, err := store.Load(t.Context())
+	if err != nil || got != nil {
+		t.Fatalf("Load() on empty store = %v, %v, want nil, nil", got, err)
+	}
+	if err := store.Save(t.Context(), token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err = store.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, token) {
+		t.Errorf("Load() = %v, want %v", *got, token)
+	}
+	if err := store.Delete(t.Context()); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	got, _ = store.Load(t.Context())
+	if got != nil {
+		t.Errorf("Load() after Delete() = %v, want nil", got)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	appFs = afero.NewMemMapFs() // replace appFs with mocked file system
+	token := Token{AccessToken: "1234", RefreshToken: "refresh", InstanceUrl: "example.com"}
+
+	if _, err := NewFileTokenStore(""); err == nil {
+		t.Error("NewFileTokenStore() with empty path should return an error")
+	}
+
+	store, err := NewFileTokenStore("tokens/token.json")
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+	got, err := store.Load(t.Context())
+	if err != nil || got != nil {
+		t.Fatalf("Load() on missing file = %v, %v, want nil, nil", got, err)
+	}
+	if err := store.Save(t.Context(), token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	info, err := appFs.Stat("tokens/token.json")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("token file permissions = %v, want 0600", info.Mode().Perm())
+	}
+	got, err = store.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, token) {
+		t.Errorf("Load() = %v, want %v", *got, token)
+	}
+	if err := store.Delete(t.Context()); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(t.Context()); err != nil {
+		t.Errorf("Delete() of missing file error = %v, want nil", err)
+	}
+
+	if err := afero.WriteFile(appFs, "tokens/token.json", []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(t.Context()); err == nil {
+		t.Error("Load() of corrupt file should return an error")
+	}
+}
@@ -0,0 +1,186 @@
+package salesforce
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Span names besides the public methods, which are traced as "salesforce.<Method>"
+const (
+	SpanHTTPRequest = "salesforce.http"
+	SpanBatch       = "salesforce.batch"
+	SpanBulkPoll    = "salesforce.bulk.poll"
+)
+
+// Attribute keys set on spans
+const (
+	AttrSObject         = "salesforce.sobject"
+	AttrExternalIdField = "salesforce.external_id_field"
+	AttrBatchSize       = "salesforce.batch_size"
+	AttrRecords         = "salesforce.records"
+	AttrRecordsFailed   = "salesforce.records_failed"
+	AttrJobId           = "salesforce.job_id"
+	AttrJobState        = "salesforce.job_state"
+	AttrOperation       = "salesforce.operation"
+	AttrHTTPMethod      = "http.request.method"
+	AttrHTTPStatus      = "http.response.status_code"
+	AttrURI             = "url.path"
+)
+
+// Attribute is a key and value set on a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts spans. Implementations find the parent span in ctx and return a ctx holding
+// the new span, which makes it easy to adapt an OpenTelemetry tracer.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// startSpan starts a span with the configured tracer, or a span that does nothing
+func (conf *configuration) startSpan(
+	ctx context.Context,
+	name string,
+	attrs ...Attribute,
+) (context.Context, Span) {
+	if conf == nil || conf.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return conf.tracer.Start(ctx, name, attrs...)
+}
+
+// startMethodSpan starts the span of a public method
+func (sf *Salesforce) startMethodSpan(
+	ctx context.Context,
+	method string,
+	attrs ...Attribute,
+) (context.Context, Span) {
+	return sf.config.startSpan(ctx, "salesforce."+method, attrs...)
+}
+
+// endSpan records *err on the span and ends it, meant to be deferred with a named error result
+func endSpan(span Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+	}
+	span.End()
+}
+
+func sObjectAttr(sObjectName string) Attribute {
+	return Attribute{Key: AttrSObject, Value: sObjectName}
+}
+
+func externalIdFieldAttr(fieldName string) Attribute {
+	return Attribute{Key: AttrExternalIdField, Value: fieldName}
+}
+
+func batchSizeAttr(batchSize int) Attribute {
+	return Attribute{Key: AttrBatchSize, Value: batchSize}
+}
+
+func jobIdAttr(jobId string) Attribute {
+	return Attribute{Key: AttrJobId, Value: jobId}
+}
+
+// RecordedSpan is a span kept by a SpanRecorder
+type RecordedSpan struct {
+	Id         int
+	ParentId   int // 0 for a root span
+	Name       string
+	Attributes map[string]any
+	Errors     []error
+	Ended      bool
+}
+
+// SpanRecorder is a Tracer that keeps the spans in memory, for tests. It is safe for
+// concurrent use.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+type spanRecorderKey struct{}
+
+type recorderSpan struct {
+	recorder *SpanRecorder
+	span     *RecordedSpan
+}
+
+// NewSpanRecorder returns an empty SpanRecorder
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (r *SpanRecorder) Start(
+	ctx context.Context,
+	name string,
+	attrs ...Attribute,
+) (context.Context, Span) {
+	parentId := 0
+	if parent, ok := ctx.Value(spanRecorderKey{}).(*recorderSpan); ok && parent.recorder == r {
+		parentId = parent.span.Id
+	}
+
+	r.mu.Lock()
+	span := &RecordedSpan{
+		Id:         len(r.spans) + 1,
+		ParentId:   parentId,
+		Name:       name,
+		Attributes: map[string]any{},
+	}
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+
+	s := &recorderSpan{recorder: r, span: span}
+	s.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanRecorderKey{}, s), s
+}
+
+// Spans returns a copy of the recorded spans in the order they were started
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, span := range r.spans {
+		spans[i] = *span
+		spans[i].Attributes = maps.Clone(span.Attributes)
+		spans[i].Errors = append([]error(nil), span.Errors...)
+	}
+	return spans
+}
+
+func (s *recorderSpan) SetAttributes(attrs ...Attribute) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	for _, attr := range attrs {
+		s.span.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *recorderSpan) RecordError(err error) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.span.Errors = append(s.span.Errors, err)
+}
+
+func (s *recorderSpan) End() {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.span.Ended = true
+}
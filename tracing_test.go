@@ -0,0 +1,216 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTracedSalesforce(t *testing.T, auth *authentication) (*Salesforce, *SpanRecorder) {
+	t.Helper()
+	recorder := NewSpanRecorder()
+	sf := buildSalesforceStruct(auth)
+	if err := WithTracer(recorder)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+	return sf, recorder
+}
+
+// spanTree renders the spans as "parent > child" names for comparing the hierarchy
+func spanTree(spans []RecordedSpan) []string {
+	names := map[int]string{}
+	tree := []string{}
+	for _, span := range spans {
+		name := span.Name
+		if parent, ok := names[span.ParentId]; ok {
+			name = parent + " > " + name
+		}
+		names[span.Id] = name
+		tree = append(tree, name)
+	}
+	return tree
+}
+
+func TestWithTracer_collection(t *testing.T) {
+	server, sfAuth := setupTestServer([]SalesforceResult{{Success: true}}, http.StatusOK)
+	defer server.Close()
+	sf, recorder := newTracedSalesforce(t, sfAuth)
+
+	records := []map[string]any{{"Name": "first"}, {"Name": "second"}}
+	if _, err := sf.InsertCollection(t.Context(), "Account", records, 1); err != nil {
+		t.Fatalf("InsertCollection() error = %v", err)
+	}
+
+	spans := recorder.Spans()
+	want := []string{
+		"salesforce.InsertCollection",
+		"salesforce.InsertCollection > salesforce.batch",
+		"salesforce.InsertCollection > salesforce.batch > salesforce.http",
+		"salesforce.InsertCollection > salesforce.batch",
+		"salesforce.InsertCollection > salesforce.batch > salesforce.http",
+	}
+	if got := spanTree(spans); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("spans =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, span := range spans {
+		if !span.Ended || len(span.Errors) > 0 {
+			t.Errorf("span %s ended = %v, errors = %v", span.Name, span.Ended, span.Errors)
+		}
+	}
+	if spans[0].Attributes[AttrSObject] != "Account" || spans[0].Attributes[AttrBatchSize] != 1 {
+		t.Errorf("method span attributes = %v", spans[0].Attributes)
+	}
+	if spans[1].Attributes[AttrBatchSize] != 1 || spans[1].Attributes[AttrRecords] != 1 ||
+		spans[1].Attributes[AttrRecordsFailed] != 0 {
+		t.Errorf("batch span attributes = %v", spans[1].Attributes)
+	}
+	httpAttrs := spans[2].Attributes
+	if httpAttrs[AttrHTTPMethod] != "POST" || httpAttrs[AttrHTTPStatus] != 200 ||
+		httpAttrs[AttrOperation] != OperationInsertCollection || httpAttrs[AttrSObject] != "Account" {
+		t.Errorf("http span attributes = %v", httpAttrs)
+	}
+}
+
+func TestWithTracer_error(t *testing.T) {
+	server, sfAuth := setupTestServer("", http.StatusOK)
+	defer server.Close()
+	sf, recorder := newTracedSalesforce(t, sfAuth)
+
+	err := sf.UpdateOne(t.Context(), "Account", map[string]any{"Name": "no id"})
+	if err == nil {
+		t.Fatal("UpdateOne() error = nil, want an error for the missing id")
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Name != "salesforce.UpdateOne" || !spans[0].Ended ||
+		len(spans[0].Errors) != 1 || spans[0].Errors[0] != err {
+		t.Errorf("span = %+v", spans[0])
+	}
+}
+
+func TestWithTracer_bulk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any
+		switch {
+		case r.Method == http.MethodPost:
+			body = bulkJob{Id: "750", State: jobStateOpen}
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+			return
+		case r.Method == http.MethodPatch:
+			body = bulkJob{Id: "750", State: jobStateUploadComplete}
+		default:
+			body = BulkJobResults{Id: "750", State: jobStateJobComplete, NumberRecordsFailed: 1}
+		}
+		data, _ := json.Marshal(body)
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf, recorder := newTracedSalesforce(t, &authentication{
+		InstanceUrl: server.URL,
+		AccessToken: "1234",
+	})
+
+	records := []map[string]any{{"Name": "first"}, {"Name": "second"}}
+	if _, err := sf.InsertBulk(t.Context(), "Account", records, 10, true); err != nil {
+		t.Fatalf("InsertBulk() error = %v", err)
+	}
+
+	spans := recorder.Spans()
+	want := []string{
+		"salesforce.InsertBulk",
+		"salesforce.InsertBulk > salesforce.batch",
+		"salesforce.InsertBulk > salesforce.batch > salesforce.http",
+		"salesforce.InsertBulk > salesforce.batch > salesforce.http",
+		"salesforce.InsertBulk > salesforce.batch > salesforce.http",
+		"salesforce.InsertBulk > salesforce.bulk.poll",
+		"salesforce.InsertBulk > salesforce.bulk.poll > salesforce.http",
+	}
+	if got := spanTree(spans); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("spans =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	batch := spans[1].Attributes
+	if batch[AttrJobId] != "750" || batch[AttrBatchSize] != 2 || batch[AttrSObject] != "Account" {
+		t.Errorf("batch span attributes = %v", batch)
+	}
+	poll := spans[5].Attributes
+	if poll[AttrJobId] != "750" || poll[AttrJobState] != jobStateJobComplete ||
+		poll[AttrRecordsFailed] != 1 {
+		t.Errorf("poll span attributes = %v", poll)
+	}
+	if spans[6].Attributes[AttrOperation] != OperationGetBulkJob {
+		t.Errorf("poll http span attributes = %v", spans[6].Attributes)
+	}
+}
+
+func TestWithTracer_nil(t *testing.T) {
+	config := configuration{}
+	config.setDefaults()
+
+	if err := WithTracer(nil)(&config); err == nil {
+		t.Error("WithTracer(nil) error = nil, want an error")
+	}
+}
+
+func TestSalesforce_uninitialized(t *testing.T) {
+	sf := &Salesforce{}
+	records := []map[string]any{{"Name": "test"}}
+	methods := map[string]func(ctx context.Context) error{
+		"DoRequest": func(ctx context.Context) error {
+			_, err := sf.DoRequest(ctx, http.MethodGet, "/limits", nil)
+			return err
+		},
+		"Query": func(ctx context.Context) error {
+			var accounts []map[string]any
+			return sf.Query(ctx, "SELECT Id FROM Account", &accounts)
+		},
+		"InsertOne": func(ctx context.Context) error {
+			_, err := sf.InsertOne(ctx, "Account", records[0])
+			return err
+		},
+		"InsertCollection": func(ctx context.Context) error {
+			_, err := sf.InsertCollection(ctx, "Account", records, 1)
+			return err
+		},
+		"InsertComposite": func(ctx context.Context) error {
+			_, err := sf.InsertComposite(ctx, "Account", records, 1, true)
+			return err
+		},
+		"InsertBulk": func(ctx context.Context) error {
+			_, err := sf.InsertBulk(ctx, "Account", records, 1, false)
+			return err
+		},
+		"QueryBulkIterator": func(ctx context.Context) error {
+			_, err := sf.QueryBulkIterator(ctx, "SELECT Id FROM Account")
+			return err
+		},
+		"Limits": func(ctx context.Context) error {
+			_, err := sf.Limits(ctx)
+			return err
+		},
+		"Identity": func(ctx context.Context) error {
+			_, err := sf.Identity(ctx)
+			return err
+		},
+		"Versions": func(ctx context.Context) error {
+			_, err := sf.Versions(ctx)
+			return err
+		},
+	}
+	for name, method := range methods {
+		t.Run(name, func(t *testing.T) {
+			err := method(t.Context())
+			if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+				t.Errorf("%s() error = %v, want not authenticated", name, err)
+			}
+		})
+	}
+}
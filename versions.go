@@ -0,0 +1,88 @@
+package salesforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// servicesDataPath is the root of the REST API, the API version follows it
+const servicesDataPath = "/services/data"
+
+// APIVersion is a REST API version the org supports
+type APIVersion struct {
+	Label   string `json:"label"`   // release name, e.g. Spring '25
+	URL     string `json:"url"`     // e.g. /services/data/v63.0
+	Version string `json:"version"` // e.g. 63.0
+}
+
+// dataPath is the path of the configured API version, e.g. /services/data/v63.0
+func (conf *configuration) dataPath() string {
+	return servicesDataPath + "/" + conf.apiVersion
+}
+
+// Versions returns the API versions the org supports, oldest first
+func (sf *Salesforce) Versions(ctx context.Context) (versions []APIVersion, err error) {
+	ctx, span := sf.startMethodSpan(ctx, "Versions")
+	defer endSpan(span, &err)
+
+	authErr := validateAuth(*sf)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	resp, err := doRequest(ctx, sf.auth, sf.config, requestPayload{
+		method:      http.MethodGet,
+		uri:         "/",
+		content:     jsonType,
+		compress:    sf.config.compressionHeaders,
+		operation:   OperationVersions,
+		unversioned: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore error since we've already read what we need
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// negotiateAPIVersion returns the newest of the org's versions that is not newer than
+// maxVersion, in the v63.0 form used by WithAPIVersion
+func negotiateAPIVersion(versions []APIVersion, maxVersion string) (string, error) {
+	newest, err := parseAPIVersion(maxVersion)
+	if err != nil {
+		return "", err
+	}
+	best := -1.0
+	for _, version := range versions {
+		number, err := parseAPIVersion(version.Version)
+		if err != nil {
+			continue // ignore versions this library cannot read
+		}
+		if number <= newest && number > best {
+			best = number
+		}
+	}
+	if best < 0 {
+		return "", errors.New("the org supports no API version up to " + maxVersion)
+	}
+	return "v" + strconv.FormatFloat(best, 'f', 1, 64), nil
+}
+
+// parseAPIVersion reads a version such as v63.0 or 63.0
+func parseAPIVersion(version string) (float64, error) {
+	number, err := strconv.ParseFloat(strings.TrimPrefix(version, "v"), 64)
+	if err != nil {
+		return 0, errors.New("invalid API version: " + version)
+	}
+	return number, nil
+}
@@ -0,0 +1,207 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testVersions = []APIVersion{
+	{Label: "Winter '24", URL: "/services/data/v59.0", Version: "59.0"},
+	{Label: "Spring '25", URL: "/services/data/v63.0", Version: "63.0"},
+	{Label: "Summer '25", URL: "/services/data/v64.0", Version: "64.0"},
+}
+
+// versionsServer serves the token endpoint and the version list
+func versionsServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any = map[string]any{}
+		switch r.URL.Path {
+		case "/services/oauth2/token":
+			body = authentication{AccessToken: "1234", InstanceUrl: server.URL}
+		case "/services/data/":
+			body = testVersions
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Error(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	return server
+}
+
+func TestSalesforce_Versions(t *testing.T) {
+	server := versionsServer(t)
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+
+	got, err := sf.Versions(t.Context())
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, testVersions) {
+		t.Errorf("Versions() = %v, want %v", got, testVersions)
+	}
+}
+
+func Test_negotiateAPIVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		versions   []APIVersion
+		maxVersion string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "org_is_newer",
+			versions:   testVersions,
+			maxVersion: "v63.0",
+			want:       "v63.0",
+		},
+		{
+			name:       "org_is_older",
+			versions:   testVersions[:1],
+			maxVersion: "v63.0",
+			want:       "v59.0",
+		},
+		{
+			name:       "unreadable_versions_are_skipped",
+			versions:   append([]APIVersion{{Version: "next"}}, testVersions...),
+			maxVersion: "v64.0",
+			want:       "v64.0",
+		},
+		{
+			name:       "no_common_version",
+			versions:   testVersions,
+			maxVersion: "v58.0",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid_max_version",
+			versions:   testVersions,
+			maxVersion: "latest",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiateAPIVersion(tt.versions, tt.maxVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("negotiateAPIVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("negotiateAPIVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAPIVersionNegotiation(t *testing.T) {
+	server := versionsServer(t)
+	defer server.Close()
+	creds := Creds{Domain: server.URL, ConsumerKey: "key", ConsumerSecret: "secret"}
+
+	sf, err := Init(creds, WithAPIVersionNegotiation(true), WithValidateAuthentication(false))
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if sf.GetAPIVersion() != apiVersion {
+		t.Errorf("GetAPIVersion() = %v, want the library version %v", sf.GetAPIVersion(), apiVersion)
+	}
+
+	sf, err = Init(creds,
+		WithAPIVersion("v60.0"),
+		WithAPIVersionNegotiation(true),
+		WithValidateAuthentication(false),
+	)
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if sf.GetAPIVersion() != "v59.0" {
+		t.Errorf("GetAPIVersion() = %v, want v59.0", sf.GetAPIVersion())
+	}
+
+	if _, err := Init(creds,
+		WithAPIVersion("v50.0"),
+		WithAPIVersionNegotiation(true),
+		WithValidateAuthentication(false),
+	); err == nil {
+		t.Error("Init() without a common API version should return an error")
+	}
+}
+
+func TestWithAPIVersion_usedByEveryPath(t *testing.T) {
+	const version = "v60.0"
+	var paths []string
+	var subRequestUrls []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var body any
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/composite"):
+			var compReq compositeRequest
+			if err := json.NewDecoder(r.Body).Decode(&compReq); err != nil {
+				t.Error(err)
+			}
+			for _, subReq := range compReq.CompositeRequest {
+				subRequestUrls = append(subRequestUrls, subReq.Url)
+			}
+			body = compositeRequestResult{CompositeResponse: []compositeSubRequestResult{{
+				Body:           []SalesforceResult{{Id: "001", Success: true}},
+				HttpStatusCode: http.StatusOK,
+			}}}
+		case strings.HasSuffix(r.URL.Path, "/query/"):
+			body = queryResponse{
+				NextRecordsUrl: "/services/data/" + version + "/query/01g-2000",
+				Records:        []map[string]any{{"Name": "first"}},
+			}
+		default:
+			body = queryResponse{Done: true, Records: []map[string]any{{"Name": "second"}}}
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Error(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			panic(err)
+		}
+	}))
+	defer server.Close()
+	sf := buildSalesforceStruct(&authentication{InstanceUrl: server.URL, AccessToken: "1234"})
+	if err := WithAPIVersion(version)(sf.config); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []map[string]any{{"Name": "test"}}
+	if _, err := sf.InsertComposite(t.Context(), "Account", records, 1, true); err != nil {
+		t.Fatalf("InsertComposite() error = %v", err)
+	}
+	var accounts []map[string]any
+	if err := sf.Query(t.Context(), "SELECT Name FROM Account", &accounts); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	wantPaths := []string{
+		"/services/data/" + version + "/composite",
+		"/services/data/" + version + "/query/",
+		"/services/data/" + version + "/query/01g-2000",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("request paths = %v, want %v", paths, wantPaths)
+	}
+	if len(subRequestUrls) != 1 ||
+		subRequestUrls[0] != "/services/data/"+version+"/composite/sobjects" {
+		t.Errorf("composite sub request urls = %v", subRequestUrls)
+	}
+	if len(accounts) != 2 {
+		t.Errorf("Query() = %v, want both pages", accounts)
+	}
+}